@@ -2,7 +2,9 @@ package apperr
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 )
 
 type AppError struct {
@@ -10,6 +12,13 @@ type AppError struct {
 	Code    Code   `json:"code"`
 	Message string `json:"message"`
 	Err     error  `json:"-"`
+	// Status overrides the HTTP status derived from Kind. Zero means no override.
+	Status int `json:"-"`
+	// Meta holds arbitrary key/value data for logs. It is never sent to clients.
+	Meta map[string]any `json:"-"`
+
+	stack      Stack
+	forceStack bool
 }
 
 func (e *AppError) Error() string {
@@ -23,26 +32,138 @@ func (e *AppError) Unwrap() error {
 	return e.Err
 }
 
+// StackTrace returns the stack captured where the error originated: the
+// innermost captured stack in the AppError chain, or nil if none was captured.
+func (e *AppError) StackTrace() Stack {
+	// Same rule as newAppError, which skips capture when a cause has a stack.
+	var cause *AppError
+	if errors.As(e.Err, &cause) {
+		if stack := cause.StackTrace(); stack != nil {
+			return stack
+		}
+	}
+	return e.stack
+}
+
+// Format implements fmt.Formatter. The %+v verb prints the error followed by
+// its stack trace, if any.
+func (e *AppError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		io.WriteString(s, e.Error())
+		if stack := e.StackTrace(); s.Flag('+') && stack != nil {
+			io.WriteString(s, "\n")
+			io.WriteString(s, stack.String())
+		}
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// PublicMessage returns the message that is safe to expose to clients. The
+// wrapped cause is only included when it is an AppError itself; any other
+// cause is considered internal.
+func (e *AppError) PublicMessage() string {
+	if cause, ok := e.Err.(*AppError); ok {
+		return fmt.Sprintf("%s: %s", e.Message, cause.PublicMessage())
+	}
+	return e.Message
+}
+
+// PublicDetails returns the MapError reachable from e through AppError causes
+// only, or nil if there is none.
+func (e *AppError) PublicDetails() *MapError {
+	switch cause := e.Err.(type) {
+	case *MapError:
+		return cause
+	case *AppError:
+		return cause.PublicDetails()
+	}
+	return nil
+}
+
+// MarshalJSON emits kind, code and the public message. When a MapError is
+// publicly reachable, its field details are emitted as a "details" object.
 func (e *AppError) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Kind    Kind   `json:"kind"`
-		Code    Code   `json:"code"`
-		Message string `json:"message"`
+		Kind    Kind      `json:"kind"`
+		Code    Code      `json:"code"`
+		Message string    `json:"message"`
+		Details *MapError `json:"details,omitempty"`
 	}{
 		Kind:    e.Kind,
 		Code:    e.Code,
-		Message: e.Error(),
+		Message: e.PublicMessage(),
+		Details: e.PublicDetails(),
 	})
 }
 
+// Option customizes an AppError on creation.
+type Option func(*AppError)
+
+// WithMeta attaches a key/value pair to the error metadata.
+func WithMeta(key string, value any) Option {
+	return func(e *AppError) {
+		if e.Meta == nil {
+			e.Meta = make(map[string]any)
+		}
+		e.Meta[key] = value
+	}
+}
+
+// WithStatus sets an explicit HTTP status, overriding the one derived from Kind.
+func WithStatus(status int) Option {
+	return func(e *AppError) {
+		e.Status = status
+	}
+}
+
 // New creates a new AppError.
-func New(kind Kind, code Code, msg string) error {
-	return &AppError{Kind: kind, Code: code, Message: msg, Err: nil}
+func New(kind Kind, code Code, msg string, opts ...Option) error {
+	return newAppError(nil, kind, code, msg, opts)
 }
 
-// Wraps an error with AppError.
-func Wrap(err error, kind Kind, code Code, msg string) error {
-	return &AppError{Kind: kind, Code: code, Message: msg, Err: err}
+// Wraps an error with AppError. The wrapped error is kept as internal cause:
+// it shows up in Error() but not in the public message.
+func Wrap(err error, kind Kind, code Code, msg string, opts ...Option) error {
+	return newAppError(err, kind, code, msg, opts)
+}
+
+// newAppError must be called directly by the exported constructors, so that the
+// captured stack starts at their caller.
+func newAppError(err error, kind Kind, code Code, msg string, opts []Option) *AppError {
+	e := &AppError{Kind: kind, Code: code, Message: msg, Err: err}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	var cause *AppError
+	hasStack := errors.As(err, &cause) && cause.StackTrace() != nil
+	if !hasStack && (e.forceStack || shouldCaptureStack(kind)) {
+		e.stack = captureStack(2)
+	}
+	return e
+}
+
+// MetaOf merges the metadata of every AppError in the chain. Outer errors take
+// precedence over inner ones on key collisions.
+func MetaOf(err error) map[string]any {
+	meta := make(map[string]any)
+	for err != nil {
+		var e *AppError
+		if !errors.As(err, &e) {
+			break
+		}
+		for k, v := range e.Meta {
+			if _, exists := meta[k]; !exists {
+				meta[k] = v
+			}
+		}
+		err = e.Err
+	}
+	return meta
 }
 
 /* ==============================================================================
@@ -51,56 +172,64 @@ func Wrap(err error, kind Kind, code Code, msg string) error {
 
 func NewValidationError(msg string, code ...Code) error {
 	if len(code) > 0 {
-		return New(Validation, code[0], msg)
+		return newAppError(nil, Validation, code[0], msg, nil)
 	}
 
-	return New(Validation, InvalidData, msg)
+	return newAppError(nil, Validation, InvalidData, msg, nil)
 }
 
 func NewRequestError(msg string, code ...Code) error {
 	if len(code) > 0 {
-		return New(Request, code[0], msg)
+		return newAppError(nil, Request, code[0], msg, nil)
 	}
 
-	return New(Request, BadRequest, msg)
+	return newAppError(nil, Request, BadRequest, msg, nil)
 }
 
 func NewUnauthorizedError(msg string, code ...Code) error {
 	if len(code) > 0 {
-		return New(Unauthorized, code[0], msg)
+		return newAppError(nil, Unauthorized, code[0], msg, nil)
 	}
 
-	return New(Unauthorized, Unauthenticated, msg)
+	return newAppError(nil, Unauthorized, Unauthenticated, msg, nil)
 }
 
 func NewForbiddenError(msg string, code ...Code) error {
 	if len(code) > 0 {
-		return New(Forbidden, code[0], msg)
+		return newAppError(nil, Forbidden, code[0], msg, nil)
 	}
 
-	return New(Forbidden, NotAllowed, msg)
+	return newAppError(nil, Forbidden, NotAllowed, msg, nil)
 }
 
 func NewConflictError(msg string, code ...Code) error {
 	if len(code) > 0 {
-		return New(Conflict, code[0], msg)
+		return newAppError(nil, Conflict, code[0], msg, nil)
+	}
+
+	return newAppError(nil, Conflict, Inconsistency, msg, nil)
+}
+
+func NewTooManyRequestsError(msg string, code ...Code) error {
+	if len(code) > 0 {
+		return newAppError(nil, TooManyRequests, code[0], msg, nil)
 	}
 
-	return New(Conflict, Inconsistency, msg)
+	return newAppError(nil, TooManyRequests, RateLimited, msg, nil)
 }
 
 func NewInternalError(msg string, code ...Code) error {
 	if len(code) > 0 {
-		return New(Internal, code[0], msg)
+		return newAppError(nil, Internal, code[0], msg, nil)
 	}
 
-	return New(Internal, Unexpected, msg)
+	return newAppError(nil, Internal, Unexpected, msg, nil)
 }
 
 func NewExternalError(msg string, code ...Code) error {
 	if len(code) > 0 {
-		return New(External, code[0], msg)
+		return newAppError(nil, External, code[0], msg, nil)
 	}
 
-	return New(External, Unexpected, msg)
+	return newAppError(nil, External, Unexpected, msg, nil)
 }
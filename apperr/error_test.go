@@ -1,10 +1,13 @@
 package apperr
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewAndWrapAppError(t *testing.T) {
@@ -30,7 +33,7 @@ func TestNewAndWrapAppError(t *testing.T) {
 	}
 }
 
-func TestMarshalJSONUsesErrorMessage(t *testing.T) {
+func TestMarshalJSONUsesPublicMessage(t *testing.T) {
 	err := Wrap(errors.New("db down"), Internal, Unexpected, "operation failed")
 	data, marshalErr := json.Marshal(err)
 	if marshalErr != nil {
@@ -48,8 +51,41 @@ func TestMarshalJSONUsesErrorMessage(t *testing.T) {
 	if got["code"] != string(Unexpected) {
 		t.Fatalf("expected code %q, got %#v", Unexpected, got["code"])
 	}
-	if !strings.Contains(got["message"].(string), "db down") {
-		t.Fatalf("expected marshaled message to include wrapped cause, got %q", got["message"])
+	if got["message"] != "operation failed" {
+		t.Fatalf("expected marshaled message to omit internal cause, got %q", got["message"])
+	}
+	if !strings.Contains(err.Error(), "db down") {
+		t.Fatalf("expected Error to keep internal cause, got %q", err.Error())
+	}
+
+	chained := Wrap(NewValidationError("must be a valid email"), Validation, InvalidData, "invalid internal data")
+	var appErr *AppError
+	errors.As(chained, &appErr)
+	if got := appErr.PublicMessage(); got != "invalid internal data: must be a valid email" {
+		t.Fatalf("expected AppError causes to be public, got %q", got)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	inner := New(Internal, Unexpected, "inner", WithMeta("table", "orders"), WithMeta("op", "insert"))
+	err := Wrap(inner, External, Unexpected, "outer", WithStatus(503), WithMeta("op", "create"))
+
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected *AppError, got %T", err)
+	}
+	if appErr.Status != 503 {
+		t.Fatalf("expected status override 503, got %d", appErr.Status)
+	}
+
+	meta := MetaOf(err)
+	if meta["table"] != "orders" || meta["op"] != "create" {
+		t.Fatalf("unexpected merged meta: %#v", meta)
+	}
+
+	data, _ := json.Marshal(err)
+	if strings.Contains(string(data), "orders") {
+		t.Fatalf("expected meta to stay out of the public body, got %s", data)
 	}
 }
 
@@ -100,3 +136,257 @@ func TestMapError(t *testing.T) {
 		t.Fatalf("unexpected map error message: %q", msg)
 	}
 }
+
+func TestMapErrorDetailsAndOrdering(t *testing.T) {
+	err := NewMapError(map[string]string{
+		"Name":  "required",
+		"Email": "invalid",
+		"Age":   "too low",
+	})
+
+	var mapErr *MapError
+	if !errors.As(err, &mapErr) {
+		t.Fatalf("expected *MapError, got %T", err)
+	}
+	if got := strings.Join(mapErr.Fields(), ","); got != "Age,Email,Name" {
+		t.Fatalf("unexpected field order: %q", got)
+	}
+	if got := err.Error(); got != "\n- Age: too low\n- Email: invalid\n- Name: required" {
+		t.Fatalf("unexpected map error message: %q", got)
+	}
+
+	details := mapErr.Details()
+	details["Name"] = "changed"
+	if mapErr.Details()["Name"] != "required" {
+		t.Fatal("expected Details to return a copy")
+	}
+}
+
+func TestMarshalJSONEmitsNestedDetails(t *testing.T) {
+	inner := Wrap(NewMapError(map[string]string{"City": "required"}), Validation, InvalidData, "invalid field(s)")
+	outer := NewMapErrorFrom(map[string]error{
+		"Name":    errors.New("required"),
+		"Address": inner,
+	})
+	err := Wrap(outer, Validation, InvalidData, "invalid field(s)")
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatalf("marshal error: %v", marshalErr)
+	}
+
+	var got struct {
+		Details map[string]any `json:"details"`
+	}
+	if unmarshalErr := json.Unmarshal(data, &got); unmarshalErr != nil {
+		t.Fatalf("unmarshal error: %v", unmarshalErr)
+	}
+
+	if got.Details["Name"] != "required" {
+		t.Fatalf("expected Name detail, got %#v", got.Details["Name"])
+	}
+	address, ok := got.Details["Address"].(map[string]any)
+	if !ok || address["City"] != "required" {
+		t.Fatalf("expected nested Address details, got %#v", got.Details["Address"])
+	}
+
+	plain, _ := json.Marshal(New(Request, BadRequest, "bad"))
+	if strings.Contains(string(plain), "details") {
+		t.Fatalf("expected no details without a MapError, got %s", plain)
+	}
+}
+
+func TestStackCapture(t *testing.T) {
+	if err := New(Internal, Unexpected, "off"); err.(*AppError).StackTrace() != nil {
+		t.Fatal("expected no stack when capture is disabled")
+	}
+
+	forced := New(Validation, InvalidData, "forced", WithStack()).(*AppError)
+	if frames := forced.StackTrace().Frames(); len(frames) == 0 || !strings.HasSuffix(frames[0].Function, "TestStackCapture") {
+		t.Fatalf("expected stack to start at caller, got %+v", frames)
+	}
+
+	CaptureStackFor(Internal)
+	defer CaptureStackFor()
+
+	internal := NewInternalError("boom").(*AppError)
+	if frames := internal.StackTrace().Frames(); len(frames) == 0 || !strings.HasSuffix(frames[0].Function, "TestStackCapture") {
+		t.Fatalf("expected stack to start at caller, got %+v", frames)
+	}
+	if NewValidationError("x").(*AppError).StackTrace() != nil {
+		t.Fatal("expected no stack for kinds not enabled")
+	}
+
+	wrapped := Wrap(internal, Internal, Unexpected, "outer").(*AppError)
+	if wrapped.stack != nil {
+		t.Fatal("expected wrap to reuse the cause stack instead of capturing a new one")
+	}
+	if len(wrapped.StackTrace()) != len(internal.StackTrace()) {
+		t.Fatal("expected wrapped error to expose the origin stack")
+	}
+
+	throughFmt := Wrap(fmt.Errorf("repo: %w", NewInternalError("db")), Internal, Unexpected, "outer").(*AppError)
+	if frames := throughFmt.StackTrace().Frames(); len(frames) == 0 || !strings.HasSuffix(frames[0].Function, "TestStackCapture") {
+		t.Fatalf("expected the stack of a cause wrapped with fmt.Errorf, got %+v", frames)
+	}
+
+	formatted := fmt.Sprintf("%+v", wrapped)
+	if !strings.HasPrefix(formatted, "outer: boom\n") || !strings.Contains(formatted, "error_test.go:") {
+		t.Fatalf("unexpected %%+v output: %q", formatted)
+	}
+	if got := fmt.Sprintf("%v", wrapped); got != "outer: boom" {
+		t.Fatalf("unexpected %%v output: %q", got)
+	}
+}
+
+// testPriceCode is registered once for the package, since Register panics on
+// duplicates and tests may run more than once (-count).
+const testPriceCode Code = "TEST_PRICE_BELOW_MINIMUM"
+
+func init() {
+	Register(testPriceCode, CodeSpec{
+		Kind:     Validation,
+		Status:   400,
+		LogLevel: LogInfo,
+		Message:  "price below minimum",
+		Messages: map[string]string{
+			"en-US": "Price must be at least {min}",
+			"pt-br": "O preço deve ser no mínimo {min}",
+		},
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	const code = testPriceCode
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected duplicate registration to panic")
+		}
+	}()
+
+	err := FromCode(code, WithMeta("min", 100))
+	var appErr *AppError
+	if !errors.As(err, &appErr) || appErr.Kind != Validation || appErr.Message != "price below minimum" {
+		t.Fatalf("unexpected error from code: %#v", err)
+	}
+
+	if got := appErr.Localized("fr", "pt").Message; got != "O preço deve ser no mínimo 100" {
+		t.Fatalf("unexpected base-language localization: %q", got)
+	}
+	if got := appErr.Localized("en-us").Message; got != "Price must be at least 100" {
+		t.Fatalf("unexpected exact localization: %q", got)
+	}
+	if got := appErr.Localized("ja-jp"); got != appErr {
+		t.Fatalf("expected unmatched locale to keep original error, got %#v", got)
+	}
+
+	Register(code, CodeSpec{Kind: Internal})
+}
+
+func TestLocalizeRegionalVariants(t *testing.T) {
+	spec := CodeSpec{Messages: map[string]string{
+		"pt-pt": "Portugal",
+		"pt-br": "Brasil",
+	}}
+
+	for i := 0; i < 20; i++ {
+		if got, _ := spec.Localize(nil, "pt"); got != "Brasil" {
+			t.Fatalf("expected the first variant in alphabetical order, got %q", got)
+		}
+	}
+	if got, _ := spec.Localize(nil, "pt-pt"); got != "Portugal" {
+		t.Fatalf("expected the exact variant, got %q", got)
+	}
+
+	spec.Messages["pt"] = "Português"
+	if got, _ := spec.Localize(nil, "pt-ao"); got != "Português" {
+		t.Fatalf("expected the bare base tag, got %q", got)
+	}
+}
+
+func TestMultiError(t *testing.T) {
+	sentinel := errors.New("db down")
+	errs := NewMultiError().
+		Add(0, "items[0].name", NewValidationError("required")).
+		Add(1, "", nil).
+		Add(2, "items[2]", fmt.Errorf("saving: %w", NewConflictError("duplicate"))).
+		Add(3, "items[3]", sentinel)
+
+	if errs.Len() != 3 {
+		t.Fatalf("expected 3 items, got %d", errs.Len())
+	}
+	if NewMultiError().Err() != nil {
+		t.Fatal("expected empty MultiError to return nil")
+	}
+
+	err := errs.Err()
+	if !errors.Is(err, sentinel) {
+		t.Fatal("expected errors.Is to find a member cause")
+	}
+	var appErr *AppError
+	if !errors.As(err, &appErr) || appErr.Kind != Validation {
+		t.Fatalf("expected errors.As to find the first member, got %#v", appErr)
+	}
+
+	if errs.Kind() != Internal {
+		t.Fatalf("expected most severe kind Internal, got %s", errs.Kind())
+	}
+	if errs.Items[1].Err.Kind != Conflict {
+		t.Fatalf("expected wrapped member to keep its kind, got %s", errs.Items[1].Err.Kind)
+	}
+	if got := errs.Items[1].Err.Error(); got != "duplicate" {
+		t.Fatalf("expected wrapped member to keep its own message, got %q", got)
+	}
+	if errs.Code() != Multiple {
+		t.Fatalf("expected mixed codes to give %s, got %s", Multiple, errs.Code())
+	}
+
+	data, _ := json.Marshal(err)
+	var got struct {
+		Kind   Kind `json:"kind"`
+		Errors []struct {
+			Index int            `json:"index"`
+			Path  string         `json:"path"`
+			Error map[string]any `json:"error"`
+		} `json:"errors"`
+	}
+	if unmarshalErr := json.Unmarshal(data, &got); unmarshalErr != nil {
+		t.Fatalf("unmarshal error: %v", unmarshalErr)
+	}
+	if got.Kind != Internal || len(got.Errors) != 3 || got.Errors[1].Index != 2 || got.Errors[0].Path != "items[0].name" {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+	if strings.Contains(string(data), "db down") {
+		t.Fatalf("expected internal cause to stay private, got %s", data)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", errors.New("boom"), false},
+		{"marked", Retryable(errors.New("boom")), true},
+		{"timeout code", NewExternalError("slow", Timeout), true},
+		{"network code", Wrap(errors.New("refused"), External, NetworkConnection, "down"), true},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), true},
+		{"canceled", context.Canceled, false},
+		{"validation", NewValidationError("bad"), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsRetryable(tc.err); got != tc.want {
+				t.Fatalf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+
+	if d, ok := RetryDelay(RetryableAfter(errors.New("throttled"), 2*time.Second)); !ok || d != 2*time.Second {
+		t.Fatalf("unexpected retry delay: %v, %v", d, ok)
+	}
+}
@@ -0,0 +1,209 @@
+// Package grpcstatus converts between apperr errors and gRPC-style statuses.
+// It mirrors the canonical gRPC codes without depending on grpc, so adapters
+// can map Status to and from google.golang.org/grpc/status with a cast.
+package grpcstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+// Code is a canonical gRPC status code. Values match google.golang.org/grpc/codes.
+type Code uint32
+
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	AlreadyExists      Code = 6
+	PermissionDenied   Code = 7
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Aborted            Code = 10
+	OutOfRange         Code = 11
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+	DataLoss           Code = 15
+	Unauthenticated    Code = 16
+)
+
+var codeNames = [...]string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable", "DataLoss",
+	"Unauthenticated",
+}
+
+func (c Code) String() string {
+	if int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return fmt.Sprintf("Code(%d)", uint32(c))
+}
+
+// ErrorInfo carries the apperr classification, like google.rpc.ErrorInfo.
+type ErrorInfo struct {
+	Reason   string            `json:"reason"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// FieldViolation describes an invalid field, like google.rpc.BadRequest.FieldViolation.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Status is a gRPC-style status: code, message and details.
+type Status struct {
+	Code            Code             `json:"code"`
+	Message         string           `json:"message"`
+	ErrorInfo       *ErrorInfo       `json:"errorInfo,omitempty"`
+	FieldViolations []FieldViolation `json:"fieldViolations,omitempty"`
+}
+
+const kindMetadataKey = "kind"
+
+// FromError converts err into a Status. Only the public parts of AppErrors are
+// used; any other error becomes Unknown with a generic message. A nil error
+// converts to OK.
+func FromError(err error) *Status {
+	if err == nil {
+		return &Status{Code: OK}
+	}
+
+	var multi *apperr.MultiError
+	if errors.As(err, &multi) {
+		s := fromAppError(multi.AppError())
+		for _, item := range multi.Items {
+			field := item.Path
+			if field == "" {
+				field = fmt.Sprintf("[%d]", item.Index)
+			}
+			s.FieldViolations = append(s.FieldViolations, FieldViolation{field, item.Err.PublicMessage()})
+		}
+		return s
+	}
+
+	var e *apperr.AppError
+	if errors.As(err, &e) {
+		return fromAppError(e)
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &Status{Code: Canceled, Message: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Status{Code: DeadlineExceeded, Message: err.Error()}
+	}
+	return &Status{Code: Unknown, Message: "unexpected error"}
+}
+
+func fromAppError(e *apperr.AppError) *Status {
+	s := &Status{
+		Code:    codeOf(e),
+		Message: e.PublicMessage(),
+		ErrorInfo: &ErrorInfo{
+			Reason:   string(e.Code),
+			Metadata: map[string]string{kindMetadataKey: string(e.Kind)},
+		},
+	}
+
+	if details := e.PublicDetails(); details != nil {
+		messages := details.Details()
+		for _, field := range details.Fields() {
+			s.FieldViolations = append(s.FieldViolations, FieldViolation{field, messages[field]})
+		}
+	}
+	return s
+}
+
+// codeOf maps an AppError to its canonical code: Validation and Request to
+// InvalidArgument, Unauthorized to Unauthenticated, Forbidden to
+// PermissionDenied, Conflict to Aborted for Inconsistency and AlreadyExists
+// otherwise, TooManyRequests to ResourceExhausted, External to Unavailable and
+// Internal to Internal. A Timeout code always maps to DeadlineExceeded.
+func codeOf(e *apperr.AppError) Code {
+	if e.Code == apperr.Timeout {
+		return DeadlineExceeded
+	}
+
+	switch e.Kind {
+	case apperr.Validation, apperr.Request:
+		return InvalidArgument
+	case apperr.Unauthorized:
+		return Unauthenticated
+	case apperr.Forbidden:
+		return PermissionDenied
+	case apperr.Conflict:
+		if e.Code == apperr.Inconsistency {
+			return Aborted
+		}
+		return AlreadyExists
+	case apperr.TooManyRequests:
+		return ResourceExhausted
+	case apperr.External:
+		return Unavailable
+	default:
+		return Internal
+	}
+}
+
+// kindOf maps a canonical code back to an apperr Kind and default Code.
+func kindOf(c Code) (apperr.Kind, apperr.Code) {
+	switch c {
+	case InvalidArgument, OutOfRange:
+		return apperr.Validation, apperr.InvalidData
+	case FailedPrecondition, NotFound, Canceled:
+		return apperr.Request, apperr.BadRequest
+	case Unauthenticated:
+		return apperr.Unauthorized, apperr.Unauthenticated
+	case PermissionDenied:
+		return apperr.Forbidden, apperr.NotAllowed
+	case AlreadyExists, Aborted:
+		return apperr.Conflict, apperr.Inconsistency
+	case ResourceExhausted:
+		return apperr.TooManyRequests, apperr.RateLimited
+	case Unavailable:
+		return apperr.External, apperr.Unexpected
+	case DeadlineExceeded:
+		return apperr.External, apperr.Timeout
+	default:
+		return apperr.Internal, apperr.Unexpected
+	}
+}
+
+// Err converts s back into an AppError, or nil for OK. The kind and code come
+// from ErrorInfo when present, falling back to the mapping of s.Code. Field
+// violations are kept as an apperr.MapError cause.
+func (s *Status) Err() error {
+	if s == nil || s.Code == OK {
+		return nil
+	}
+
+	kind, code := kindOf(s.Code)
+	if s.ErrorInfo != nil {
+		if s.ErrorInfo.Reason != "" {
+			code = apperr.Code(s.ErrorInfo.Reason)
+		}
+		if k := s.ErrorInfo.Metadata[kindMetadataKey]; k != "" {
+			kind = apperr.Kind(k)
+		}
+	}
+
+	if len(s.FieldViolations) == 0 {
+		return apperr.New(kind, code, s.Message)
+	}
+
+	details := make(map[string]string, len(s.FieldViolations))
+	for _, v := range s.FieldViolations {
+		details[v.Field] = v.Description
+	}
+	return apperr.Wrap(apperr.NewMapError(details), kind, code, s.Message)
+}
@@ -0,0 +1,98 @@
+package grpcstatus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+func TestFromErrorKindMapping(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		code Code
+	}{
+		{"nil", nil, OK},
+		{"validation", apperr.NewValidationError("x"), InvalidArgument},
+		{"request", apperr.NewRequestError("x"), InvalidArgument},
+		{"unauthorized", apperr.NewUnauthorizedError("x"), Unauthenticated},
+		{"forbidden", apperr.NewForbiddenError("x"), PermissionDenied},
+		{"conflict inconsistency", apperr.NewConflictError("x"), Aborted},
+		{"conflict duplicate", apperr.NewConflictError("x", "DUPLICATE_EMAIL"), AlreadyExists},
+		{"too many requests", apperr.NewTooManyRequestsError("x"), ResourceExhausted},
+		{"external", apperr.NewExternalError("x"), Unavailable},
+		{"timeout", apperr.NewExternalError("x", apperr.Timeout), DeadlineExceeded},
+		{"internal", apperr.NewInternalError("x"), Internal},
+		{"canceled", context.Canceled, Canceled},
+		{"deadline", context.DeadlineExceeded, DeadlineExceeded},
+		{"plain", errors.New("db down"), Unknown},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FromError(tc.err).Code; got != tc.code {
+				t.Fatalf("expected %s, got %s", tc.code, got)
+			}
+		})
+	}
+
+	if msg := FromError(errors.New("db down")).Message; msg != "unexpected error" {
+		t.Fatalf("expected plain errors to be hidden, got %q", msg)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	original := apperr.Wrap(
+		apperr.NewMapError(map[string]string{"Email": "required", "Name": "too short"}),
+		apperr.Validation, "SIGNUP_INVALID", "invalid field(s)",
+	)
+
+	s := FromError(original)
+	if s.Code != InvalidArgument || s.ErrorInfo.Reason != "SIGNUP_INVALID" {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+	if len(s.FieldViolations) != 2 || s.FieldViolations[0].Field != "Email" {
+		t.Fatalf("unexpected field violations: %+v", s.FieldViolations)
+	}
+
+	var appErr *apperr.AppError
+	if !errors.As(s.Err(), &appErr) {
+		t.Fatalf("expected AppError, got %T", s.Err())
+	}
+	if appErr.Kind != apperr.Validation || appErr.Code != "SIGNUP_INVALID" || appErr.Message != "invalid field(s)" {
+		t.Fatalf("unexpected round trip: %+v", appErr)
+	}
+	if details := appErr.PublicDetails(); details == nil || details.Details()["Name"] != "too short" {
+		t.Fatalf("expected field details to survive, got %v", details)
+	}
+}
+
+func TestErrWithoutErrorInfo(t *testing.T) {
+	if (&Status{Code: OK}).Err() != nil {
+		t.Fatal("expected OK status to convert to nil")
+	}
+
+	var appErr *apperr.AppError
+	if !errors.As((&Status{Code: PermissionDenied, Message: "nope"}).Err(), &appErr) {
+		t.Fatal("expected AppError")
+	}
+	if appErr.Kind != apperr.Forbidden || appErr.Code != apperr.NotAllowed {
+		t.Fatalf("unexpected kind/code: (%s, %s)", appErr.Kind, appErr.Code)
+	}
+}
+
+func TestFromMultiError(t *testing.T) {
+	errs := apperr.NewMultiError().
+		Add(0, "items[0]", apperr.NewValidationError("bad price")).
+		Add(2, "", apperr.NewValidationError("bad name"))
+
+	s := FromError(errs.Err())
+	if s.Code != InvalidArgument || len(s.FieldViolations) != 2 {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+	if s.FieldViolations[1].Field != "[2]" || s.FieldViolations[1].Description != "bad name" {
+		t.Fatalf("unexpected violation: %+v", s.FieldViolations[1])
+	}
+}
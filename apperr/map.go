@@ -1,12 +1,19 @@
 package apperr
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// MapError holds field-level error details, keyed by field name.
+// Fields whose error is itself a MapError keep their nested structure.
 type MapError struct {
 	details map[string]string
+	nested  map[string]*MapError
 }
 
 func (e *MapError) Error() string {
@@ -15,14 +22,89 @@ func (e *MapError) Error() string {
 	}
 	var sb strings.Builder
 
-	// Write each field error on a new line.
-	for field, msg := range e.details {
-		sb.WriteString(fmt.Sprintf("\n- %s: %s", field, msg))
+	// Write each field error on a new line, sorted by field name.
+	for _, field := range e.Fields() {
+		sb.WriteString(fmt.Sprintf("\n- %s: %s", field, e.details[field]))
 	}
 
 	return sb.String()
 }
 
+// Fields returns the field names with errors in ascending order.
+func (e *MapError) Fields() []string {
+	fields := make([]string, 0, len(e.details))
+	for field := range e.details {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Details returns a copy of the field -> message map.
+func (e *MapError) Details() map[string]string {
+	details := make(map[string]string, len(e.details))
+	for field, msg := range e.details {
+		details[field] = msg
+	}
+	return details
+}
+
+// Nested returns the MapError of a field whose error carries its own field details.
+func (e *MapError) Nested(field string) (*MapError, bool) {
+	nested, ok := e.nested[field]
+	return nested, ok
+}
+
+// MarshalJSON emits an object of field -> message, with nested MapErrors as nested objects.
+func (e *MapError) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(e.details))
+	for field, msg := range e.details {
+		if nested, ok := e.nested[field]; ok {
+			out[field] = nested
+		} else {
+			out[field] = msg
+		}
+	}
+	return json.Marshal(out)
+}
+
 func NewMapError(details map[string]string) error {
 	return &MapError{details: details}
 }
+
+// NewMapErrorFrom creates a MapError from field errors. If a field error has a
+// MapError in its chain, its details are kept as a nested object. A MultiError
+// is nested as an object of its members, keyed by path or else by index.
+func NewMapErrorFrom(errs map[string]error) error {
+	e := &MapError{details: make(map[string]string, len(errs))}
+	for field, err := range errs {
+		e.details[field] = err.Error()
+
+		var (
+			multi  *MultiError
+			nested *MapError
+		)
+		if errors.As(err, &multi) {
+			nested = mapErrorOfItems(multi)
+		} else if !errors.As(err, &nested) {
+			continue
+		}
+		if e.nested == nil {
+			e.nested = make(map[string]*MapError)
+		}
+		e.nested[field] = nested
+	}
+	return e
+}
+
+func mapErrorOfItems(m *MultiError) *MapError {
+	errs := make(map[string]error, len(m.Items))
+	for _, item := range m.Items {
+		key := item.Path
+		if key == "" {
+			key = strconv.Itoa(item.Index)
+		}
+		errs[key] = item.Err
+	}
+	return NewMapErrorFrom(errs).(*MapError)
+}
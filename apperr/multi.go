@@ -0,0 +1,196 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ItemError is a member of a MultiError, located by its index and/or path.
+type ItemError struct {
+	Index int
+	Path  string
+	Err   *AppError
+}
+
+func (i ItemError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Index int       `json:"index"`
+		Path  string    `json:"path,omitempty"`
+		Error *AppError `json:"error"`
+	}{
+		Index: i.Index,
+		Path:  i.Path,
+		Error: i.Err,
+	})
+}
+
+// MultiError aggregates the errors of a batch operation, so it need not stop
+// at the first failure. errors.Is and errors.As see every member.
+//
+// Usage:
+//
+//	errs := apperr.NewMultiError()
+//	for i, item := range items {
+//		errs.Add(i, "", process(item))
+//	}
+//	if err := errs.Err(); err != nil { ... }
+type MultiError struct {
+	Items []ItemError
+
+	// summary, if set, is the AppError that summarizes m instead of its items.
+	summary *AppError
+}
+
+func NewMultiError() *MultiError {
+	return &MultiError{}
+}
+
+// Add appends err at the given index and path. Nil errors are ignored. An
+// error with an AppError in its chain is added as that AppError; others are
+// wrapped as Internal.
+func (m *MultiError) Add(index int, path string, err error) *MultiError {
+	if err == nil {
+		return m
+	}
+	var e *AppError
+	if !errors.As(err, &e) {
+		e = newAppError(err, Internal, Unexpected, "unexpected error", nil)
+	}
+	m.Items = append(m.Items, ItemError{Index: index, Path: path, Err: e})
+	return m
+}
+
+// Len returns the number of collected errors.
+func (m *MultiError) Len() int {
+	return len(m.Items)
+}
+
+// Err returns m if it holds any error, or nil otherwise.
+func (m *MultiError) Err() error {
+	if len(m.Items) == 0 {
+		return nil
+	}
+	return m
+}
+
+func (m *MultiError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d error(s) occurred:", len(m.Items)))
+	for _, item := range m.Items {
+		if item.Path != "" {
+			sb.WriteString(fmt.Sprintf("\n- [%d] %s: %v", item.Index, item.Path, item.Err))
+		} else {
+			sb.WriteString(fmt.Sprintf("\n- [%d] %v", item.Index, item.Err))
+		}
+	}
+	return sb.String()
+}
+
+func (m *MultiError) Unwrap() []error {
+	errs := make([]error, len(m.Items))
+	for i, item := range m.Items {
+		errs[i] = item.Err
+	}
+	return errs
+}
+
+var kindSeverity = map[Kind]int{
+	Request:         1,
+	Validation:      2,
+	Unauthorized:    3,
+	Forbidden:       4,
+	TooManyRequests: 5,
+	Conflict:        6,
+	External:        7,
+	Internal:        8,
+}
+
+// Kind returns the most severe Kind among the members, from Request (least
+// severe) to Internal. Unknown kinds count as Internal.
+func (m *MultiError) Kind() Kind {
+	var kind Kind
+	severity := 0
+	for _, item := range m.Items {
+		s, ok := kindSeverity[item.Err.Kind]
+		if !ok {
+			s = kindSeverity[Internal]
+		}
+		if s > severity {
+			kind, severity = item.Err.Kind, s
+		}
+	}
+	return kind
+}
+
+// Code returns the code shared by all members, or Multiple if they differ.
+func (m *MultiError) Code() Code {
+	if len(m.Items) == 0 {
+		return Multiple
+	}
+	code := m.Items[0].Err.Code
+	for _, item := range m.Items[1:] {
+		if item.Err.Code != code {
+			return Multiple
+		}
+	}
+	return code
+}
+
+// AppError summarizes m as a single AppError of its overall Kind and Code,
+// wrapping m as the cause. A MultiError from Summarized takes the kind, code
+// and public message of its summary instead.
+func (m *MultiError) AppError() *AppError {
+	if m.summary != nil {
+		return &AppError{
+			Kind:    m.summary.Kind,
+			Code:    m.summary.Code,
+			Message: m.summary.PublicMessage(),
+			Err:     m,
+		}
+	}
+	return &AppError{
+		Kind:    m.Kind(),
+		Code:    m.Code(),
+		Message: fmt.Sprintf("%d error(s) occurred", len(m.Items)),
+		Err:     m,
+	}
+}
+
+// Summarized returns a copy of m summarized by e, such as an AppError that
+// wraps m to classify the batch as a whole.
+func (m *MultiError) Summarized(e *AppError) *MultiError {
+	return &MultiError{Items: m.Items, summary: e}
+}
+
+// Localized returns a copy of m whose members and summary are localized as in
+// AppError.Localized.
+func (m *MultiError) Localized(langs ...string) *MultiError {
+	localized := &MultiError{Items: make([]ItemError, len(m.Items))}
+	for i, item := range m.Items {
+		item.Err = item.Err.Localized(langs...)
+		localized.Items[i] = item
+	}
+	if m.summary != nil {
+		localized.summary = m.summary.Localized(langs...)
+	}
+	return localized
+}
+
+// MarshalJSON emits the summary kind, code and message with the list of member
+// errors under "errors".
+func (m *MultiError) MarshalJSON() ([]byte, error) {
+	summary := m.AppError()
+	return json.Marshal(struct {
+		Kind    Kind        `json:"kind"`
+		Code    Code        `json:"code"`
+		Message string      `json:"message"`
+		Errors  []ItemError `json:"errors"`
+	}{
+		Kind:    summary.Kind,
+		Code:    summary.Code,
+		Message: summary.Message,
+		Errors:  m.Items,
+	})
+}
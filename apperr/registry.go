@@ -0,0 +1,138 @@
+package apperr
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// LogLevel is the severity an error should be logged with. LogDefault leaves
+// the decision to the caller (e.g. httpserver derives it from Kind).
+type LogLevel int
+
+const (
+	LogDefault LogLevel = iota
+	LogDebug
+	LogInfo
+	LogWarn
+	LogError
+	LogFatal
+)
+
+// CodeSpec describes a registered error code.
+type CodeSpec struct {
+	// Kind is used by FromCode when creating errors of this code.
+	Kind Kind
+	// Status is the default HTTP status. Zero means derive it from Kind.
+	Status int
+	// LogLevel is the default log level. LogDefault means derive it from Kind.
+	LogLevel LogLevel
+	// Message is the fallback message when no localized one matches.
+	Message string
+	// Messages holds message templates keyed by lowercase locale tag (e.g.
+	// prim.Locale values such as "pt-br"). Placeholders in the form {key} are
+	// replaced by the error metadata.
+	Messages map[string]string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Code]CodeSpec{}
+)
+
+// Register registers a code with its spec. It panics if the code is already
+// registered, so each code must be registered once, usually from an init func.
+func Register(code Code, spec CodeSpec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[code]; exists {
+		panic(fmt.Sprintf("apperr: code %q already registered", code))
+	}
+
+	messages := make(map[string]string, len(spec.Messages))
+	for locale, msg := range spec.Messages {
+		messages[strings.ToLower(locale)] = msg
+	}
+	spec.Messages = messages
+	registry[code] = spec
+}
+
+// Lookup returns the spec registered for code.
+func Lookup(code Code) (CodeSpec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	spec, ok := registry[code]
+	return spec, ok
+}
+
+// FromCode creates an AppError with the registered kind and fallback message of
+// code. Unregistered codes produce an Internal error.
+func FromCode(code Code, opts ...Option) error {
+	spec, ok := Lookup(code)
+	if !ok {
+		return newAppError(nil, Internal, code, string(code), opts)
+	}
+	return newAppError(nil, spec.Kind, code, spec.Message, opts)
+}
+
+// Localize returns the message template that best matches the preferred
+// languages, in order, with placeholders filled from meta. An exact locale
+// match wins over a base language match ("pt" matches "pt-br"), which prefers
+// the bare base tag, then the first regional variant in alphabetical order.
+func (s CodeSpec) Localize(meta map[string]any, langs ...string) (string, bool) {
+	for _, lang := range langs {
+		lang = strings.ToLower(lang)
+		if tmpl, ok := s.Messages[lang]; ok {
+			return fillTemplate(tmpl, meta), true
+		}
+
+		base, _, _ := strings.Cut(lang, "-")
+		if tmpl, ok := s.Messages[base]; ok {
+			return fillTemplate(tmpl, meta), true
+		}
+		locales := slices.Sorted(maps.Keys(s.Messages))
+		for _, locale := range locales {
+			if localeBase, _, _ := strings.Cut(locale, "-"); localeBase == base {
+				return fillTemplate(s.Messages[locale], meta), true
+			}
+		}
+	}
+	return "", false
+}
+
+func fillTemplate(tmpl string, meta map[string]any) string {
+	if len(meta) == 0 {
+		return tmpl
+	}
+	oldnew := make([]string, 0, len(meta)*2)
+	for k, v := range meta {
+		oldnew = append(oldnew, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(oldnew...).Replace(tmpl)
+}
+
+// Localized returns a copy of e suited for a response body in the preferred
+// languages. If e's code is registered with a matching message, the copy has
+// that message and keeps only the public field details; otherwise e is returned.
+func (e *AppError) Localized(langs ...string) *AppError {
+	spec, ok := Lookup(e.Code)
+	if !ok {
+		return e
+	}
+	msg, ok := spec.Localize(MetaOf(e), langs...)
+	if !ok {
+		return e
+	}
+
+	localized := *e
+	localized.Message = msg
+	localized.Err = nil
+	if details := e.PublicDetails(); details != nil {
+		localized.Err = details
+	}
+	return &localized
+}
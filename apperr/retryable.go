@@ -0,0 +1,68 @@
+package apperr
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+)
+
+type retryableError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable wraps an error to mark it as worth retrying.
+func Retryable(err error) error {
+	return &retryableError{Err: err}
+}
+
+// RetryableAfter marks an error as worth retrying no sooner than after d,
+// e.g. honouring a Retry-After header.
+func RetryableAfter(err error, d time.Duration) error {
+	return &retryableError{Err: err, After: d}
+}
+
+// IsRetryable checks if the error is worth retrying: it is either marked as
+// Retryable, has an AppError with Timeout or NetworkConnection code in its
+// chain, or is a context deadline, a bad driver connection or a network timeout.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var retryableErr *retryableError
+	if errors.As(err, &retryableErr) {
+		return true
+	}
+
+	var appErr *AppError
+	if errors.As(err, &appErr) && (appErr.Code == Timeout || appErr.Code == NetworkConnection) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryDelay returns the minimum delay hinted by RetryableAfter, if any.
+func RetryDelay(err error) (time.Duration, bool) {
+	var retryableErr *retryableError
+	if errors.As(err, &retryableErr) && retryableErr.After > 0 {
+		return retryableErr.After, true
+	}
+	return 0, false
+}
@@ -0,0 +1,74 @@
+package apperr
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+const maxStackDepth = 32
+
+var stackKinds atomic.Pointer[[]Kind]
+
+// CaptureStackFor enables stack capture on New and Wrap for errors of the given
+// kinds. Capturing is off by default so hot paths (e.g. validation) pay nothing;
+// call it with no kinds to turn it off again.
+//
+//	apperr.CaptureStackFor(apperr.Internal, apperr.External)
+func CaptureStackFor(kinds ...Kind) {
+	kinds = slices.Clone(kinds)
+	stackKinds.Store(&kinds)
+}
+
+func shouldCaptureStack(kind Kind) bool {
+	kinds := stackKinds.Load()
+	return kinds != nil && slices.Contains(*kinds, kind)
+}
+
+// WithStack forces stack capture regardless of the global setting.
+func WithStack() Option {
+	return func(e *AppError) {
+		e.forceStack = true
+	}
+}
+
+// Stack is a captured call stack, innermost frame first.
+type Stack []uintptr
+
+// Frames resolves the program counters into runtime frames.
+func (s Stack) Frames() []runtime.Frame {
+	if len(s) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(s)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// String formats the stack as one "function\n\tfile:line" entry per frame.
+func (s Stack) String() string {
+	var sb strings.Builder
+	for i, frame := range s.Frames() {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(fmt.Sprintf("%s\n\t%s:%d", frame.Function, frame.File, frame.Line))
+	}
+	return sb.String()
+}
+
+// captureStack records the stack starting skip frames above its caller.
+func captureStack(skip int) Stack {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return Stack(pcs[:n])
+}
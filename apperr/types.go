@@ -3,15 +3,28 @@ package apperr
 type Code string
 
 const (
-	Unknown           Code = "UNKNOWN"
-	Unexpected        Code = "UNEXPECTED"
-	Timeout           Code = "TIMEOUT"
-	NetworkConnection Code = "NETWORK_CONNECTION"
-	BadRequest        Code = "BAD_REQUEST"
-	InvalidData       Code = "INVALID_DATA"
-	Inconsistency     Code = "INCONSISTENCY"
-	Unauthenticated   Code = "UNAUTHENTICATED"
-	NotAllowed        Code = "NOT_ALLOWED"
+	Unknown              Code = "UNKNOWN"
+	Unexpected           Code = "UNEXPECTED"
+	Timeout              Code = "TIMEOUT"
+	NetworkConnection    Code = "NETWORK_CONNECTION"
+	BadRequest           Code = "BAD_REQUEST"
+	InvalidData          Code = "INVALID_DATA"
+	Inconsistency        Code = "INCONSISTENCY"
+	Unauthenticated      Code = "UNAUTHENTICATED"
+	NotAllowed           Code = "NOT_ALLOWED"
+	Multiple             Code = "MULTIPLE_ERRORS"
+	NotAcceptable        Code = "NOT_ACCEPTABLE"
+	MalformedJSON        Code = "MALFORMED_JSON"
+	InvalidPathParam     Code = "INVALID_PATH_PARAM"
+	InvalidQueryParam    Code = "INVALID_QUERY_PARAM"
+	InvalidHeader        Code = "INVALID_HEADER"
+	InvalidFormValue     Code = "INVALID_FORM_VALUE"
+	UnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	BodyTooLarge         Code = "BODY_TOO_LARGE"
+	PreconditionFailed   Code = "PRECONDITION_FAILED"
+	IdempotencyKeyReused Code = "IDEMPOTENCY_KEY_REUSED"
+	RequestInProgress    Code = "REQUEST_IN_PROGRESS"
+	RateLimited          Code = "RATE_LIMITED"
 )
 
 type Kind string
@@ -24,4 +37,5 @@ const (
 	Conflict          Kind = "Conflict"
 	Internal          Kind = "Internal"
 	External          Kind = "External"
+	TooManyRequests   Kind = "TooManyRequests"
 )
@@ -19,3 +19,11 @@ type Store interface {
 	GetJSON(key string, v interface{}) error
 	Clear(key string)
 }
+
+// AtomicStore is a Store that can also cache a value only if its key is not
+// set yet, as a single operation. It is used to take locks across instances.
+type AtomicStore interface {
+	Store
+	// AddJSON caches v unless key exists, and reports whether it did.
+	AddJSON(key string, v interface{}, duration time.Duration) (bool, error)
+}
@@ -2,17 +2,20 @@ package memorydb
 
 import (
 	"context"
+	"sync"
 
 	"github.com/kgjoner/cornucopia/v3/cache"
 )
 
 // Simple in memory cache for tests or pocs. It does not implement duration.
 type Pool struct {
+	mu   *sync.RWMutex
 	data map[string]string
 }
 
 func NewPool() (*Pool, error) {
 	return &Pool{
+		mu:   &sync.RWMutex{},
 		data: map[string]string{},
 	}, nil
 }
@@ -24,12 +27,14 @@ func (p *Pool) Close() error {
 
 type Store struct {
 	ctx  context.Context
+	mu   *sync.RWMutex
 	data map[string]string
 }
 
 func (p *Pool) NewStore(ctx context.Context) cache.Store {
 	return &Store{
 		ctx:  ctx,
+		mu:   p.mu,
 		data: p.data,
 	}
 }
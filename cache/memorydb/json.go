@@ -13,12 +13,31 @@ func (q Store) CacheJSON(key string, v interface{}, duration time.Duration) erro
 		return err
 	}
 
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	q.data[key] = string(data)
 	return nil
 }
 
+func (q Store) AddJSON(key string, v interface{}, duration time.Duration) (bool, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, exists := q.data[key]; exists {
+		return false, nil
+	}
+	q.data[key] = string(data)
+	return true, nil
+}
+
 func (q Store) GetJSON(key string, v interface{}) error {
+	q.mu.RLock()
 	jsonData, exists := q.data[key]
+	q.mu.RUnlock()
 	if !exists {
 		return cache.ErrNil
 	}
@@ -32,5 +51,7 @@ func (q Store) GetJSON(key string, v interface{}) error {
 }
 
 func (q Store) Clear(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	delete(q.data, key)
 }
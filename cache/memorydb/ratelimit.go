@@ -0,0 +1,122 @@
+package memorydb
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/cache"
+)
+
+// sweepEvery is how many hits RateLimiter takes between sweeps of the keys
+// it no longer needs.
+const sweepEvery = 1024
+
+// RateLimiter is an in-process cache.RateLimiter. Its counts are not shared
+// across instances, so it suits single instances, tests or pocs.
+type RateLimiter struct {
+	mu      sync.Mutex
+	entries map[string]*rateEntry
+	hits    int
+	now     func() time.Time
+}
+
+type rateEntry struct {
+	// tokens and last are the TokenBucket state.
+	tokens float64
+	last   time.Time
+	// log holds the SlidingWindow hits, from the oldest.
+	log []time.Time
+	// expires is when the entry is back to its initial state.
+	expires time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		entries: map[string]*rateEntry{},
+		now:     time.Now,
+	}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, rate cache.Rate) (cache.RateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return cache.RateResult{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.hits++
+	if l.hits%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	e, ok := l.entries[key]
+	if !ok || !now.Before(e.expires) {
+		e = &rateEntry{}
+		l.entries[key] = e
+	}
+
+	if rate.Algorithm == cache.SlidingWindow {
+		return e.slide(rate, now), nil
+	}
+	return e.take(rate, now), nil
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, e := range l.entries {
+		if !now.Before(e.expires) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// take takes a token from the bucket, refilled since the last hit.
+func (e *rateEntry) take(rate cache.Rate, now time.Time) cache.RateResult {
+	capacity := float64(rate.Limit)
+	perToken := float64(rate.Window) / capacity
+
+	if e.last.IsZero() {
+		e.tokens = capacity
+	} else if elapsed := now.Sub(e.last); elapsed > 0 {
+		e.tokens = math.Min(capacity, e.tokens+float64(elapsed)/perToken)
+	}
+	e.last = now
+
+	var res cache.RateResult
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - e.tokens) * perToken))
+	}
+	res.Remaining = int(e.tokens)
+	res.Reset = time.Duration(math.Ceil((capacity - e.tokens) * perToken))
+	e.expires = now.Add(res.Reset)
+	return res
+}
+
+// slide logs the hit if fewer than the limit happened within the window.
+func (e *rateEntry) slide(rate cache.Rate, now time.Time) cache.RateResult {
+	start := 0
+	for start < len(e.log) && now.Sub(e.log[start]) >= rate.Window {
+		start++
+	}
+	e.log = e.log[start:]
+
+	var res cache.RateResult
+	if len(e.log) < rate.Limit {
+		e.log = append(e.log, now)
+		res.Allowed = true
+	} else {
+		res.RetryAfter = e.log[0].Add(rate.Window).Sub(now)
+	}
+	res.Remaining = rate.Limit - len(e.log)
+	if len(e.log) > 0 {
+		e.expires = e.log[len(e.log)-1].Add(rate.Window)
+		res.Reset = e.expires.Sub(now)
+	}
+	return res
+}
@@ -0,0 +1,85 @@
+package memorydb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/cache"
+)
+
+func newTestRateLimiter() (*RateLimiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter()
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestTokenBucket(t *testing.T) {
+	l, now := newTestRateLimiter()
+	rate := cache.Rate{Limit: 3, Window: 3 * time.Second, Algorithm: cache.TokenBucket}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, _ := l.Allow(ctx, "k", rate)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("expected hit %d allowed with %d remaining, got %+v", i+1, 2-i, res)
+		}
+	}
+
+	res, _ := l.Allow(ctx, "k", rate)
+	if res.Allowed || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Fatalf("expected denied hit retrying after 1s and resetting in 3s, got %+v", res)
+	}
+
+	// A token is refilled every second.
+	*now = now.Add(time.Second)
+	res, _ = l.Allow(ctx, "k", rate)
+	if !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected refilled hit allowed, got %+v", res)
+	}
+
+	res, _ = l.Allow(ctx, "other", rate)
+	if !res.Allowed || res.Remaining != 2 {
+		t.Errorf("expected other key to have its own bucket, got %+v", res)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	l, now := newTestRateLimiter()
+	rate := cache.Rate{Limit: 2, Window: time.Minute, Algorithm: cache.SlidingWindow}
+	ctx := context.Background()
+
+	l.Allow(ctx, "k", rate)
+	*now = now.Add(30 * time.Second)
+	res, _ := l.Allow(ctx, "k", rate)
+	if !res.Allowed || res.Remaining != 0 || res.Reset != time.Minute {
+		t.Fatalf("expected second hit allowed, got %+v", res)
+	}
+
+	res, _ = l.Allow(ctx, "k", rate)
+	if res.Allowed || res.RetryAfter != 30*time.Second {
+		t.Fatalf("expected denied hit retrying when the first one leaves the window, got %+v", res)
+	}
+
+	*now = now.Add(30 * time.Second)
+	res, _ = l.Allow(ctx, "k", rate)
+	if !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected hit allowed once the first one left the window, got %+v", res)
+	}
+}
+
+func TestRateLimiterSweepsExpiredKeys(t *testing.T) {
+	l, now := newTestRateLimiter()
+	rate := cache.Rate{Limit: 1, Window: time.Second}
+
+	l.Allow(context.Background(), "old", rate)
+	*now = now.Add(time.Minute)
+	for i := 0; i < sweepEvery; i++ {
+		l.Allow(context.Background(), "new", rate)
+	}
+
+	if _, ok := l.entries["old"]; ok {
+		t.Error("expected expired key to be swept")
+	}
+}
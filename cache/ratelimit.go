@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// RateAlgorithm is how a RateLimiter counts hits against a Rate.
+type RateAlgorithm int
+
+const (
+	// TokenBucket refills Limit tokens evenly over Window, each hit taking
+	// one. It allows bursts of up to Limit hits.
+	TokenBucket RateAlgorithm = iota
+	// SlidingWindow allows at most Limit hits within any span of Window.
+	SlidingWindow
+)
+
+// Rate is a limit of Limit hits per Window.
+type Rate struct {
+	Limit     int
+	Window    time.Duration
+	Algorithm RateAlgorithm
+}
+
+// RateResult is the outcome of a hit.
+type RateResult struct {
+	Allowed bool
+	// Remaining is how many hits are left once this one is counted.
+	Remaining int
+	// Reset is how long until the whole limit is available again.
+	Reset time.Duration
+	// RetryAfter is how long until a hit is allowed again, when not Allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimiter counts hits of keys against a Rate. A key should always be hit
+// with the same Rate.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, rate Rate) (RateResult, error)
+}
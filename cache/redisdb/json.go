@@ -2,8 +2,12 @@ package redisdb
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"time"
 
+	"github.com/kgjoner/cornucopia/v3/apperr"
 	"github.com/kgjoner/cornucopia/v3/cache"
 	"github.com/redis/go-redis/v9"
 )
@@ -14,13 +18,23 @@ func (q Store) CacheJSON(key string, v interface{}, duration time.Duration) erro
 		return err
 	}
 
-	return q.db.Set(q.ctx, key, string(data), duration).Err()
+	return classify(q.db.Set(q.ctx, key, string(data), duration).Err())
+}
+
+func (q Store) AddJSON(key string, v interface{}, duration time.Duration) (bool, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+
+	added, err := q.db.SetNX(q.ctx, key, string(data), duration).Result()
+	return added, classify(err)
 }
 
 func (q Store) GetJSON(key string, v interface{}) error {
 	jsonData, err := q.db.Get(q.ctx, key).Result()
 	if err != nil && err != redis.Nil {
-		return err
+		return classify(err)
 	} else if err == redis.Nil {
 		return cache.ErrNil
 	}
@@ -36,3 +50,16 @@ func (q Store) GetJSON(key string, v interface{}) error {
 func (q Store) Clear(key string) {
 	q.db.Del(q.ctx, key)
 }
+
+// classify marks network failures as retryable.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return apperr.Retryable(err)
+	}
+	return err
+}
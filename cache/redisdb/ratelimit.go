@@ -0,0 +1,115 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// Both scripts read the clock from Redis, so that instances with skewed
+// clocks share consistent counts, and return {allowed, remaining, reset,
+// retryAfter} with durations in milliseconds.
+
+// tokenBucketScript keeps the tokens left and the time of the last hit in a
+// hash, refilling the tokens on each hit.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local per_token = window / capacity
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1])
+if tokens == nil then
+	tokens = capacity
+else
+	tokens = math.min(capacity, tokens + math.max(0, now - tonumber(state[2])) / per_token)
+end
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * per_token)
+end
+
+local reset = math.ceil((capacity - tokens) * per_token)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// slidingWindowScript logs the hits of the window in a sorted set scored by
+// their time.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed, retry = 0, 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, now .. ':' .. ARGV[3])
+	count = count + 1
+	allowed = 1
+else
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	retry = tonumber(oldest[2]) + window - now
+end
+
+local reset = 0
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+if newest[2] then
+	reset = tonumber(newest[2]) + window - now
+	redis.call('PEXPIRE', KEYS[1], math.max(reset, 1))
+end
+return {allowed, limit - count, reset, retry}
+`)
+
+// RateLimiter is a cache.RateLimiter whose counts live in Redis and are
+// updated atomically by Lua scripts, so that they are shared across instances.
+type RateLimiter struct {
+	db *redis.Client
+}
+
+// NewRateLimiter returns a RateLimiter on client, e.g. a Pool's Client.
+func NewRateLimiter(client *redis.Client) *RateLimiter {
+	return &RateLimiter{client}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, rate cache.Rate) (cache.RateResult, error) {
+	var (
+		res []int64
+		err error
+	)
+	window := rate.Window.Milliseconds()
+	if rate.Algorithm == cache.SlidingWindow {
+		// Hits logged within the same millisecond need distinct members.
+		nonce := fmt.Sprintf("%016x", rand.Uint64())
+		res, err = slidingWindowScript.Run(ctx, l.db, []string{key}, rate.Limit, window, nonce).Int64Slice()
+	} else {
+		res, err = tokenBucketScript.Run(ctx, l.db, []string{key}, rate.Limit, window).Int64Slice()
+	}
+	if err != nil {
+		return cache.RateResult{}, classify(err)
+	}
+	if len(res) != 4 {
+		return cache.RateResult{}, fmt.Errorf("redisdb: unexpected rate limit script result: %v", res)
+	}
+
+	return cache.RateResult{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		Reset:      time.Duration(res[2]) * time.Millisecond,
+		RetryAfter: time.Duration(res[3]) * time.Millisecond,
+	}, nil
+}
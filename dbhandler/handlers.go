@@ -2,6 +2,8 @@ package dbhandler
 
 import (
 	"database/sql"
+	"fmt"
+	"slices"
 
 	"github.com/kgjoner/cornucopia/v3/prim"
 )
@@ -33,6 +35,40 @@ func HandleListQuery[T any](rows *sql.Rows, pag *prim.Pagination, dest func(item
 	return prim.NewPaginatedData(*pag, items), nil
 }
 
+// HandleListQueryWithTotal is HandleListQuery for a query that selects the
+// total count of rows, e.g. COUNT(*) OVER(), as its last column.
+func HandleListQueryWithTotal[T any](rows *sql.Rows, pag *prim.Pagination, dest func(item *T) []any) (*prim.PaginatedData[T], error) {
+	var total int
+	data, err := HandleListQuery(rows, pag, func(item *T) []any {
+		return append(dest(item), &total)
+	})
+	if err != nil || data == nil {
+		return data, err
+	}
+
+	// With no rows the count is unknown, unless this is the first page.
+	if len(data.Data) > 0 || pag.Page == 0 {
+		data.SetTotal(total)
+	}
+	return data, nil
+}
+
+// HandleListQueryWithCount is HandleListQuery with the total count of rows
+// read from a separate count query, e.g. SELECT COUNT(*) with the same WHERE.
+func HandleListQueryWithCount[T any](rows *sql.Rows, count *sql.Row, pag *prim.Pagination, dest func(item *T) []any) (*prim.PaginatedData[T], error) {
+	data, err := HandleListQuery(rows, pag, dest)
+	if err != nil || data == nil {
+		return data, err
+	}
+
+	var total int
+	if err := count.Scan(&total); err != nil {
+		return nil, err
+	}
+	data.SetTotal(total)
+	return data, nil
+}
+
 func HandleListQueryWithoutPagination[T any](rows *sql.Rows, dest func(item *T) []any) ([]T, error) {
 	items := []T{}
 	for rows.Next() {
@@ -54,3 +90,52 @@ func HandleListQueryWithoutPagination[T any](rows *sql.Rows, dest func(item *T)
 
 	return items, nil
 }
+
+// HandleCursorListQuery scans a page of a keyset-paginated query. The query
+// must select up to pag.Limit+1 rows after pag.Cursor in ORDER BY order or,
+// when pag.IsBackward(), before it in reverse order. keys returns the sort keys
+// of an item, in ORDER BY order, used to build the next and prev cursors.
+func HandleCursorListQuery[T any](rows *sql.Rows, pag *prim.CursorPagination, dest func(item *T) []any, keys func(item *T) []any) (*prim.CursorPaginatedData[T], error) {
+	if pag.Limit <= 0 {
+		rows.Close()
+		return nil, fmt.Errorf("dbhandler: cursor pagination limit must be positive, got %d", pag.Limit)
+	}
+
+	items, err := HandleListQueryWithoutPagination(rows, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(items) > pag.Limit
+	if hasMore {
+		items = items[:pag.Limit]
+	}
+	backward := pag.IsBackward()
+	if backward {
+		slices.Reverse(items)
+	}
+
+	data := &prim.CursorPaginatedData[T]{
+		Data:  items,
+		Limit: pag.Limit,
+	}
+	if len(items) == 0 {
+		return data, nil
+	}
+
+	// Going forward, there are later rows if more were found; going backward,
+	// there are the ones the cursor came from. The same holds for earlier rows.
+	if hasMore || backward {
+		if data.NextCursor, err = prim.NewCursor(keys(&items[len(items)-1])...); err != nil {
+			return nil, err
+		}
+	}
+	if backward && hasMore || !backward && pag.Cursor != nil {
+		if data.PrevCursor, err = prim.NewCursor(keys(&items[0])...); err != nil {
+			return nil, err
+		}
+		data.PrevCursor.Backward = true
+	}
+
+	return data, nil
+}
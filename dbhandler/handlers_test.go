@@ -0,0 +1,189 @@
+package dbhandler
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+)
+
+// rowsDriver serves the rows of the DSN's entry in fakeRows to any query.
+type rowsDriver struct{}
+
+var fakeRows = map[string][][]driver.Value{}
+
+func init() {
+	sql.Register("dbhandler-rows", rowsDriver{})
+}
+
+func (rowsDriver) Open(name string) (driver.Conn, error) { return rowsConn(name), nil }
+
+type rowsConn string
+
+func (c rowsConn) Prepare(query string) (driver.Stmt, error) { return rowsStmt(c), nil }
+func (c rowsConn) Close() error                              { return nil }
+func (c rowsConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type rowsStmt string
+
+func (s rowsStmt) Close() error                                    { return nil }
+func (s rowsStmt) NumInput() int                                   { return -1 }
+func (s rowsStmt) Exec(args []driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (s rowsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeResult{rows: fakeRows[string(s)]}, nil
+}
+
+type fakeResult struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeResult) Columns() []string {
+	if len(r.rows) > 0 && len(r.rows[0]) == 2 {
+		return []string{"id", "total"}
+	}
+	return []string{"id"}
+}
+func (r *fakeResult) Close() error { return nil }
+func (r *fakeResult) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func queryIDs(t *testing.T, ids ...int64) *sql.Rows {
+	t.Helper()
+	values := make([][]driver.Value, len(ids))
+	for i, id := range ids {
+		values[i] = []driver.Value{id}
+	}
+	return queryRows(t, values)
+}
+
+func queryRows(t *testing.T, values [][]driver.Value) *sql.Rows {
+	t.Helper()
+	fakeRows[t.Name()] = values
+
+	db, err := sql.Open("dbhandler-rows", t.Name())
+	if err != nil {
+		t.Fatalf("unexpected open error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rows, err := db.Query("SELECT id")
+	if err != nil {
+		t.Fatalf("unexpected query error: %v", err)
+	}
+	return rows
+}
+
+type cursorItem struct {
+	ID int64
+}
+
+func cursorID(t *testing.T, c *prim.Cursor) int64 {
+	t.Helper()
+	if c == nil {
+		return -1
+	}
+	var id int64
+	if err := c.Scan(&id); err != nil {
+		t.Fatalf("unexpected cursor error: %v", err)
+	}
+	return id
+}
+
+func TestHandleCursorListQuery(t *testing.T) {
+	dest := func(item *cursorItem) []any { return []any{&item.ID} }
+	keys := func(item *cursorItem) []any { return []any{item.ID} }
+	after, _ := prim.NewCursor(int64(3))
+	before := after.Reversed()
+
+	tests := []struct {
+		name       string
+		cursor     *prim.Cursor
+		rows       []int64
+		wantIDs    []int64
+		wantNext   int64
+		wantPrev   int64
+		wantPrevBw bool
+	}{
+		{"first page", nil, []int64{1, 2, 3}, []int64{1, 2}, 2, -1, false},
+		{"last page", nil, []int64{1, 2}, []int64{1, 2}, -1, -1, false},
+		{"forward from cursor", after, []int64{4, 5, 6}, []int64{4, 5}, 5, 4, true},
+		{"backward with more", before, []int64{2, 1, 0}, []int64{1, 2}, 2, 1, true},
+		{"backward to start", before, []int64{2, 1}, []int64{1, 2}, 2, -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pag := prim.NewCursorPagination(tt.cursor, 2)
+			data, err := HandleCursorListQuery(queryIDs(t, tt.rows...), pag, dest, keys)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var ids []int64
+			for _, item := range data.Data {
+				ids = append(ids, item.ID)
+			}
+			if len(ids) != len(tt.wantIDs) || ids[0] != tt.wantIDs[0] || ids[len(ids)-1] != tt.wantIDs[len(tt.wantIDs)-1] {
+				t.Errorf("expected ids %v, got %v", tt.wantIDs, ids)
+			}
+			if got := cursorID(t, data.NextCursor); got != tt.wantNext {
+				t.Errorf("expected next cursor at %d, got %d", tt.wantNext, got)
+			}
+			if got := cursorID(t, data.PrevCursor); got != tt.wantPrev {
+				t.Errorf("expected prev cursor at %d, got %d", tt.wantPrev, got)
+			}
+			if data.NextCursor != nil && data.NextCursor.Backward {
+				t.Error("expected next cursor to read forward")
+			}
+			if data.PrevCursor != nil && data.PrevCursor.Backward != tt.wantPrevBw {
+				t.Error("expected prev cursor to read backward")
+			}
+		})
+	}
+}
+
+func TestHandleCursorListQueryRejectsNonPositiveLimit(t *testing.T) {
+	dest := func(item *cursorItem) []any { return []any{&item.ID} }
+	keys := func(item *cursorItem) []any { return []any{item.ID} }
+
+	pag := &prim.CursorPagination{Limit: -1}
+	if _, err := HandleCursorListQuery(queryIDs(t, 1, 2), pag, dest, keys); err == nil {
+		t.Error("expected non-positive limit to be rejected")
+	}
+}
+
+func TestHandleListQueryWithTotal(t *testing.T) {
+	dest := func(item *cursorItem) []any { return []any{&item.ID} }
+
+	pag := prim.NewPagination(&prim.PaginationCreationFields{Page: 1, Limit: 2})
+	rows := queryRows(t, [][]driver.Value{{int64(3), int64(5)}, {int64(4), int64(5)}, {int64(5), int64(5)}})
+	data, err := HandleListQueryWithTotal(rows, pag, dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data.Data) != 2 || !data.HasNext {
+		t.Errorf("expected 2 items and a next page, got %+v", data)
+	}
+	if data.Total == nil || *data.Total != 5 || data.TotalPages == nil || *data.TotalPages != 3 {
+		t.Errorf("expected 5 items in 3 pages, got %v %v", data.Total, data.TotalPages)
+	}
+
+	pastEnd := prim.NewPagination(&prim.PaginationCreationFields{Page: 9, Limit: 2})
+	t.Run("past the end", func(t *testing.T) {
+		data, err := HandleListQueryWithTotal(queryRows(t, nil), pastEnd, dest)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data.Total != nil {
+			t.Errorf("expected unknown total, got %d", *data.Total)
+		}
+	})
+}
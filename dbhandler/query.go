@@ -0,0 +1,123 @@
+package dbhandler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+)
+
+// Placeholder is the bind parameter syntax of a SQL driver.
+type Placeholder int
+
+const (
+	// Dollar numbers parameters as $1, $2... (PostgreSQL).
+	Dollar Placeholder = iota
+	// Question writes every parameter as ? (MySQL, SQLite).
+	Question
+)
+
+// columnPattern matches plain or table-qualified column names. Anything else
+// is rejected rather than quoted, so that no client input reaches the SQL.
+var columnPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+func checkColumn(column string) error {
+	if !columnPattern.MatchString(column) {
+		return fmt.Errorf("dbhandler: invalid column name %q", column)
+	}
+	return nil
+}
+
+// OrderBy renders sort as an ORDER BY clause, or "" when it is empty.
+func OrderBy(sort prim.Sort) (string, error) {
+	if len(sort) == 0 {
+		return "", nil
+	}
+
+	parts := make([]string, len(sort))
+	for i, f := range sort {
+		if err := checkColumn(f.Column); err != nil {
+			return "", err
+		}
+		if f.Desc {
+			parts[i] = f.Column + " DESC"
+		} else {
+			parts[i] = f.Column + " ASC"
+		}
+	}
+	return "ORDER BY " + strings.Join(parts, ", "), nil
+}
+
+// Where renders filters as a WHERE clause, with their values as parameters.
+// Dollar parameters are numbered from argOffset+1, to follow the ones already
+// in the query. It returns "" and no args when filters is empty.
+//
+//	where, args, err := dbhandler.Where(filters, dbhandler.Dollar, 0)
+//	query := "SELECT id, name FROM items " + where
+func Where(filters prim.Filters, placeholder Placeholder, argOffset int) (string, []any, error) {
+	if len(filters) == 0 {
+		return "", nil, nil
+	}
+
+	var args []any
+	param := func(v any) string {
+		args = append(args, v)
+		if placeholder == Question {
+			return "?"
+		}
+		return "$" + strconv.Itoa(argOffset+len(args))
+	}
+
+	conds := make([]string, len(filters))
+	for i, f := range filters {
+		if err := checkColumn(f.Column); err != nil {
+			return "", nil, err
+		}
+		if len(f.Values) == 0 {
+			return "", nil, fmt.Errorf("dbhandler: filter on %s has no value", f.Field)
+		}
+
+		switch f.Op {
+		case prim.OpEq, prim.OpNe, prim.OpGt, prim.OpGte, prim.OpLt, prim.OpLte:
+			conds[i] = f.Column + " " + comparisons[f.Op] + " " + param(f.Values[0])
+		case prim.OpIn, prim.OpNin:
+			params := make([]string, len(f.Values))
+			for j, v := range f.Values {
+				params[j] = param(v)
+			}
+			op := "IN"
+			if f.Op == prim.OpNin {
+				op = "NOT IN"
+			}
+			conds[i] = f.Column + " " + op + " (" + strings.Join(params, ", ") + ")"
+		case prim.OpLike:
+			pattern := "%" + likeEscaper.Replace(fmt.Sprint(f.Values[0])) + "%"
+			conds[i] = f.Column + " LIKE " + param(pattern) + " ESCAPE '!'"
+		case prim.OpExist:
+			if exists, _ := f.Values[0].(bool); exists {
+				conds[i] = f.Column + " IS NOT NULL"
+			} else {
+				conds[i] = f.Column + " IS NULL"
+			}
+		default:
+			return "", nil, fmt.Errorf("dbhandler: unsupported filter operator %q", f.Op)
+		}
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+var comparisons = map[prim.FilterOp]string{
+	prim.OpEq:  "=",
+	prim.OpNe:  "<>",
+	prim.OpGt:  ">",
+	prim.OpGte: ">=",
+	prim.OpLt:  "<",
+	prim.OpLte: "<=",
+}
+
+// likeEscaper escapes the LIKE wildcards of a value matched as a substring.
+// It escapes with "!" rather than a backslash, which MySQL would read as an
+// escape inside the ESCAPE literal itself.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
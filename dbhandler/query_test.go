@@ -0,0 +1,75 @@
+package dbhandler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+)
+
+func TestOrderBy(t *testing.T) {
+	got, err := OrderBy(prim.Sort{{Field: "createdAt", Column: "i.created_at", Desc: true}, {Field: "name", Column: "name"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "ORDER BY i.created_at DESC, name ASC"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if _, err := OrderBy(prim.Sort{{Field: "x", Column: "name; DROP TABLE items"}}); err == nil {
+		t.Error("expected invalid column to be rejected")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	filters := prim.Filters{
+		{Field: "status", Column: "status", Op: prim.OpIn, Values: []any{"active", "pending"}},
+		{Field: "price", Column: "price", Op: prim.OpGte, Values: []any{100.0}},
+		{Field: "name", Column: "name", Op: prim.OpLike, Values: []any{"50%_off!"}},
+		{Field: "deletedAt", Column: "deleted_at", Op: prim.OpExist, Values: []any{false}},
+	}
+	wantArgs := []any{"active", "pending", 100.0, "%50!%!_off!!%"}
+
+	tests := []struct {
+		placeholder Placeholder
+		offset      int
+		want        string
+	}{
+		{Dollar, 1, `WHERE status IN ($2, $3) AND price >= $4 AND name LIKE $5 ESCAPE '!' AND deleted_at IS NULL`},
+		{Question, 0, `WHERE status IN (?, ?) AND price >= ? AND name LIKE ? ESCAPE '!' AND deleted_at IS NULL`},
+	}
+	for _, tt := range tests {
+		got, args, err := Where(filters, tt.placeholder, tt.offset)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, got)
+		}
+		if !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("expected args %v, got %v", wantArgs, args)
+		}
+	}
+
+	if got, args, err := Where(nil, Dollar, 0); got != "" || args != nil || err != nil {
+		t.Errorf("expected empty clause, got %q %v %v", got, args, err)
+	}
+	if _, _, err := Where(prim.Filters{{Column: "a b", Op: prim.OpEq, Values: []any{1}}}, Dollar, 0); err == nil {
+		t.Error("expected invalid column to be rejected")
+	}
+}
+
+func TestWhereLikeEscapesPortably(t *testing.T) {
+	filters := prim.Filters{{Field: "name", Column: "name", Op: prim.OpLike, Values: []any{`a\b!c%d_e`}}}
+
+	got, args, err := Where(filters, Question, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "WHERE name LIKE ? ESCAPE '!'"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if want := []any{`%a\b!!c!%d!_e%`}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
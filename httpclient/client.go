@@ -2,13 +2,22 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/requestid"
 )
 
 type Client struct {
@@ -29,6 +38,8 @@ func New(baseURL string) *Client {
 type Options struct {
 	Params  map[string]string
 	Headers map[string]string
+	// Context is attached to the request. Its request ID, if any, is forwarded.
+	Context context.Context
 }
 
 func (u *Client) SetDefaultOptions(opt *Options) {
@@ -78,6 +89,9 @@ func (u Client) request(method string, path string, inputtedBody map[string]any,
 
 	if opt != nil {
 		SetOptions(req, *opt)
+		if opt.Context != nil {
+			req = req.WithContext(opt.Context)
+		}
 	}
 
 	if body != nil {
@@ -105,12 +119,56 @@ func SetOptions(req *http.Request, opt Options) {
 	}
 }
 
+// isNetworkError tells whether err, as returned by http.Client.Do, is a
+// failure to reach or talk to the server, which may not happen again.
+func isNetworkError(err error) bool {
+	// *url.Error, which wraps every Do error, is a net.Error itself.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+
+	var (
+		opErr  *net.OpError
+		netErr net.Error
+	)
+	return errors.As(err, &opErr) || errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
 // DoReq executes the HTTP request and decodes the response into the provided data structure.
-// It also handles error responses by wrapping them in a custom error type.
+// The request ID of the request context, if any, is forwarded in the X-Request-ID header.
+// It also handles error responses by converting them to an External AppError, whose
+// metadata carries the request and response details. Network failures and
+// 429/502/503/504 responses are marked as retryable (see apperr.IsRetryable),
+// unlike requests that cannot succeed, such as ones with an unsupported scheme
+// or an untrusted certificate.
 func DoReq(client *http.Client, req *http.Request, data any) (*http.Response, error) {
+	if id, ok := requestid.FromContext(req.Context()); ok && req.Header.Get(requestid.Header) == "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
 	res, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if apperr.IsRetryable(err) {
+			return nil, apperr.Wrap(err, apperr.External, apperr.Timeout, "upstream request timed out",
+				apperr.WithStatus(http.StatusGatewayTimeout))
+		}
+		if isNetworkError(err) {
+			return nil, apperr.Wrap(err, apperr.External, apperr.NetworkConnection, "upstream request failed",
+				apperr.WithStatus(http.StatusBadGateway))
+		}
+		return nil, apperr.Wrap(err, apperr.External, apperr.Unexpected, "upstream request failed",
+			apperr.WithStatus(http.StatusBadGateway))
 	}
 	defer res.Body.Close()
 
@@ -118,15 +176,23 @@ func DoReq(client *http.Client, req *http.Request, data any) (*http.Response, er
 		var bodyErr map[string]any
 		json.NewDecoder(res.Body).Decode(&bodyErr)
 
-		err := apperr.NewMapError(map[string]string{
-			"RequestMethod":  req.Method,
-			"RequestURL":     req.URL.String(),
-			"ResponseStatus": fmt.Sprint(res.StatusCode),
-			"ResponseBody":   fmt.Sprint(bodyErr),
-		})
-
+		var opts []apperr.Option
 		msg, _ := bodyErr["message"].(string)
-		code, _ := bodyErr["code"].(apperr.Code)
+		codeStr, _ := bodyErr["code"].(string)
+		code := apperr.Code(codeStr)
+
+		// RFC 9457 problem details carry the message in detail (or title).
+		if isProblemDetails(res) {
+			if msg, _ = bodyErr["detail"].(string); msg == "" {
+				msg, _ = bodyErr["title"].(string)
+			}
+			if problemType, _ := bodyErr["type"].(string); problemType != "" {
+				opts = append(opts, apperr.WithMeta("ProblemType", problemType))
+			}
+			if instance, _ := bodyErr["instance"].(string); instance != "" {
+				opts = append(opts, apperr.WithMeta("ProblemInstance", instance))
+			}
+		}
 
 		if code == "" {
 			switch res.StatusCode {
@@ -145,7 +211,23 @@ func DoReq(client *http.Client, req *http.Request, data any) (*http.Response, er
 			}
 		}
 
-		return res, apperr.Wrap(err, apperr.External, code, msg)
+		opts = append(opts,
+			apperr.WithMeta("RequestMethod", req.Method),
+			apperr.WithMeta("RequestURL", req.URL.String()),
+			apperr.WithMeta("ResponseStatus", res.StatusCode),
+			apperr.WithMeta("ResponseBody", fmt.Sprint(bodyErr)),
+		)
+		err := apperr.New(apperr.External, code, msg, opts...)
+
+		switch res.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			if seconds, convErr := strconv.Atoi(res.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+				return res, apperr.RetryableAfter(err, time.Duration(seconds)*time.Second)
+			}
+			return res, apperr.Retryable(err)
+		}
+
+		return res, err
 	}
 
 	if data != nil {
@@ -154,3 +236,8 @@ func DoReq(client *http.Client, req *http.Request, data any) (*http.Response, er
 
 	return res, nil
 }
+
+func isProblemDetails(res *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return mediaType == "application/problem+json"
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kgjoner/cornucopia/v3/apperr"
 )
@@ -108,3 +109,78 @@ func TestClientRequestWithDefaultOptions(t *testing.T) {
 		t.Fatalf("unexpected payload: %#v", out)
 	}
 }
+
+func TestDoReqProblemDetails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"type":"https://errors.example.com/price-too-low","title":"Unprocessable Entity","status":422,"detail":"price too low","instance":"/orders","code":"PRICE_TOO_LOW"}`))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	_, err = DoReq(srv.Client(), req, nil)
+	var appErr *apperr.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected AppError, got %T", err)
+	}
+	if appErr.Kind != apperr.External || appErr.Code != "PRICE_TOO_LOW" {
+		t.Fatalf("unexpected kind/code: (%s, %s)", appErr.Kind, appErr.Code)
+	}
+	if appErr.Message != "price too low" {
+		t.Fatalf("expected detail as message, got %q", appErr.Message)
+	}
+	if appErr.Meta["ProblemType"] != "https://errors.example.com/price-too-low" || appErr.Meta["ProblemInstance"] != "/orders" {
+		t.Fatalf("unexpected meta: %#v", appErr.Meta)
+	}
+}
+
+func TestDoReqMarksRetryableStatuses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := DoReq(srv.Client(), req, nil)
+	if !apperr.IsRetryable(err) {
+		t.Fatalf("expected 503 to be retryable, got %v", err)
+	}
+	if d, ok := apperr.RetryDelay(err); !ok || d != 3*time.Second {
+		t.Fatalf("expected Retry-After hint of 3s, got %v", d)
+	}
+
+	srv.Close()
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err = DoReq(srv.Client(), req, nil)
+	var appErr *apperr.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperr.NetworkConnection || !apperr.IsRetryable(err) {
+		t.Fatalf("expected retryable network error, got %v", err)
+	}
+}
+
+func TestDoReqDoesNotRetryPermanentFailures(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "ftp://example.com/file", nil)
+	_, err := DoReq(http.DefaultClient, req, nil)
+
+	var appErr *apperr.AppError
+	if !errors.As(err, &appErr) || appErr.Kind != apperr.External || appErr.Code != apperr.Unexpected {
+		t.Fatalf("expected External/Unexpected error, got %v", err)
+	}
+	if apperr.IsRetryable(err) {
+		t.Fatalf("expected unsupported scheme not to be retryable, got %v", err)
+	}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err = DoReq(&http.Client{}, req, nil)
+	if !errors.As(err, &appErr) || appErr.Code != apperr.Unexpected || apperr.IsRetryable(err) {
+		t.Fatalf("expected untrusted certificate not to be retryable, got %v", err)
+	}
+}
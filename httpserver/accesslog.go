@@ -0,0 +1,73 @@
+package httpserver
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/logger"
+)
+
+// AccessLogOptions configures AccessLog.
+type AccessLogOptions struct {
+	// SampleRate is the fraction, in (0, 1], of successful requests to log.
+	// Error responses (status >= 400) are always logged. Zero logs every request.
+	SampleRate float64
+	// ExcludePaths lists request paths that are never logged, e.g. "/health".
+	ExcludePaths []string
+}
+
+// AccessLog returns a middleware that emits one log entry per request, with
+// method, route, status, bytes, duration, IP, actor and request ID, plus the
+// error kind, code and metadata for error responses. Success and Error do not
+// log requests handled by it.
+func AccessLog(opts AccessLogOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(opts.ExcludePaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := wrapResponseWriter(w)
+			r, info := withResponseInfo(r)
+			info.accessLogged = true
+
+			next.ServeHTTP(rw, r)
+
+			status := rw.Status()
+			if status < 400 && opts.SampleRate > 0 && opts.SampleRate < 1 && rand.Float64() >= opts.SampleRate {
+				return
+			}
+
+			handlerReq := r
+			if info.req != nil {
+				handlerReq = info.req
+			}
+
+			fields := logger.Fields{
+				"Route":      routePattern(r),
+				"Status":     status,
+				"Bytes":      rw.bytes,
+				"DurationMs": float64(time.Since(start).Microseconds()) / 1000,
+				"IP":         ParseIP(r),
+			}
+
+			if info.err != nil {
+				logRequest(handlerReq, info.logLevel, info.err.Error(), info.err, fields)
+				return
+			}
+
+			level := logger.LevelInfo
+			switch {
+			case status >= 500:
+				level = logger.LevelError
+			case status >= 400:
+				level = logger.LevelWarn
+			}
+			logRequest(handlerReq, level, "request completed", nil, fields)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package httpserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+	"github.com/kgjoner/cornucopia/v3/logger"
+)
+
+type logEntry struct {
+	level  logger.Level
+	msg    string
+	fields logger.Fields
+}
+
+// captureLogs routes httpserver logs into the returned slice until the test ends.
+func captureLogs(t *testing.T) *[]logEntry {
+	var entries []logEntry
+	httpserver.SetLogger(logger.Func(func(ctx context.Context, level logger.Level, msg string, fields logger.Fields) {
+		entries = append(entries, logEntry{level, msg, fields})
+	}))
+	t.Cleanup(func() { httpserver.SetLogger(nil) })
+	return &entries
+}
+
+func TestAccessLog(t *testing.T) {
+	logs := captureLogs(t)
+
+	router := chi.NewRouter()
+	router.Use(httpserver.RequestID)
+	router.Use(httpserver.AccessLog(httpserver.AccessLogOptions{ExcludePaths: []string{"/health"}}))
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), httpserver.ActorLogKey, "user-1")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		httpserver.Success("ok", w, r)
+	})
+	router.Post("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "0" {
+			httpserver.Error(apperr.NewValidationError("invalid id"), w, r)
+			return
+		}
+		httpserver.Success("created", w, r, http.StatusCreated)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if len(*logs) != 0 {
+		t.Fatalf("Expected excluded path not to be logged, got %d entries", len(*logs))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/items/1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(*logs) != 1 {
+		t.Fatalf("Expected exactly one entry per request, got %d", len(*logs))
+	}
+	entry := (*logs)[0]
+	if entry.level != logger.LevelInfo || entry.fields["Status"] != http.StatusCreated || entry.fields["Route"] != "/items/{id}" {
+		t.Errorf("Unexpected entry: %v %v", entry.level, entry.fields)
+	}
+	if entry.fields["Actor"] != "user-1" || entry.fields["IP"] != "10.0.0.1:1234" || entry.fields["RequestID"] == "" {
+		t.Errorf("Unexpected entry data: %v", entry.fields)
+	}
+	if entry.fields["Bytes"].(int) == 0 {
+		t.Errorf("Expected response size to be recorded")
+	}
+
+	*logs = nil
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items/0", nil))
+	if len(*logs) != 1 {
+		t.Fatalf("Expected exactly one entry for error response, got %d", len(*logs))
+	}
+	entry = (*logs)[0]
+	if entry.level != logger.LevelWarn || entry.fields["Kind"] != apperr.Validation || entry.fields["Status"] != http.StatusUnprocessableEntity {
+		t.Errorf("Unexpected error entry: %v %v", entry.level, entry.fields)
+	}
+}
+
+func TestAccessLogKeepsHijacker(t *testing.T) {
+	handler := httpserver.AccessLog(httpserver.AccessLogOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("Expected the wrapped writer to be an http.Hijacker")
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			t.Errorf("Expected hijack to succeed, got %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	}))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected 101, got %d", res.StatusCode)
+	}
+}
@@ -2,6 +2,8 @@ package httpserver
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"encoding"
 	"encoding/json"
 	"fmt"
@@ -13,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/kgjoner/cornucopia/v3/apperr"
 	"github.com/kgjoner/cornucopia/v3/media"
 	"github.com/kgjoner/cornucopia/v3/prim"
 )
@@ -29,33 +32,112 @@ import (
 type Binder struct {
 	r   *http.Request
 	err error
+
+	maxBodySize           int64
+	disallowUnknownFields bool
 }
 
+// MaxCursorLimit is the largest page size ParseCursorPagination accepts.
+const MaxCursorLimit = 100
+
+// DefaultMaxBodySize is the largest body JSONBody reads unless changed with
+// Binder.MaxBodySize.
+const DefaultMaxBodySize = 10 << 20
+
 // Bind creates a Binder for the given request.
 func Bind(r *http.Request) *Binder {
-	return &Binder{r: r}
+	return &Binder{r: r, maxBodySize: DefaultMaxBodySize}
+}
+
+// MaxBodySize sets the largest request body, once decompressed, that JSONBody
+// reads. Larger bodies fail with BODY_TOO_LARGE (413). n <= 0 removes the limit.
+func (b *Binder) MaxBodySize(n int64) *Binder {
+	b.maxBodySize = n
+	return b
+}
+
+// DisallowUnknownFields makes JSONBody reject objects with fields dst does not have.
+func (b *Binder) DisallowUnknownFields() *Binder {
+	b.disallowUnknownFields = true
+	return b
 }
 
-// Err returns the first error encountered during binding, or nil.
+// Err returns the first error encountered during binding, or nil. Invalid
+// input is reported as an apperr Request error whose code tells its source
+// (e.g. INVALID_QUERY_PARAM) and whose details name the offending param, so it
+// can be passed straight to Error. Misuse of the Binder, such as an
+// unsupported destination type, is returned as a plain error.
 func (b *Binder) Err() error {
 	return b.err
 }
 
-// JSONBody decodes the request body as JSON into dst.
+// JSONBody decodes the request body as a single JSON value into dst. gzip and
+// deflate Content-Encodings are decompressed. A Content-Type other than JSON
+// is rejected with UNSUPPORTED_MEDIA_TYPE, and decoding failures, including
+// data after the value, are reported as MALFORMED_JSON with the JSON path and
+// offset.
 func (b *Binder) JSONBody(dst any) *Binder {
 	if b.err != nil {
 		return b
 	}
+	if b.err = checkContentType(b.r, "application/json", isJSONMediaType); b.err != nil {
+		return b
+	}
 	defer b.r.Body.Close()
-	body, err := io.ReadAll(b.r.Body)
+
+	body, err := b.body()
 	if err != nil {
 		b.err = err
 		return b
 	}
-	b.err = json.Unmarshal(body, dst)
+	defer body.Close()
+
+	dec := json.NewDecoder(body)
+	if b.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		b.err = decodeError(err)
+		return b
+	}
+	end := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		b.err = trailingDataError(err, end)
+	}
 	return b
 }
 
+// body returns the request body, decompressed and limited to maxBodySize.
+func (b *Binder) body() (io.ReadCloser, error) {
+	var body io.ReadCloser
+	switch encoding := strings.ToLower(strings.TrimSpace(b.r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		body = io.NopCloser(b.r.Body)
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(b.r.Body)
+		if err != nil {
+			return nil, bodyError(err)
+		}
+		body = zr
+	case "deflate":
+		zr, err := zlib.NewReader(b.r.Body)
+		if err != nil {
+			return nil, bodyError(err)
+		}
+		body = zr
+	default:
+		return nil, apperr.New(apperr.Request, apperr.UnsupportedMediaType,
+			fmt.Sprintf("unsupported content encoding %q", encoding),
+			apperr.WithStatus(http.StatusUnsupportedMediaType),
+		)
+	}
+
+	if b.maxBodySize > 0 {
+		body = http.MaxBytesReader(nil, body, b.maxBodySize)
+	}
+	return body, nil
+}
+
 // PathParam binds a chi URL path parameter to dst.
 // dst must be a *string, a numeric/bool pointer, or implement encoding.TextUnmarshaler.
 func (b *Binder) PathParam(name string, dst any) *Binder {
@@ -63,7 +145,7 @@ func (b *Binder) PathParam(name string, dst any) *Binder {
 		return b
 	}
 
-	b.err = bindText(chi.URLParam(b.r, name), dst)
+	b.err = bindParam(pathSource, name, chi.URLParam(b.r, name), dst)
 	return b
 }
 
@@ -74,7 +156,7 @@ func (b *Binder) QueryParam(name string, dst any) *Binder {
 		return b
 	}
 	if val := b.r.URL.Query().Get(name); val != "" {
-		b.err = bindText(val, dst)
+		b.err = bindParam(querySource, name, val, dst)
 	}
 	return b
 }
@@ -98,7 +180,7 @@ func (b *Binder) Header(name string, dst any) *Binder {
 		return b
 	}
 	if val := b.r.Header.Get(name); val != "" {
-		b.err = bindText(val, dst)
+		b.err = bindParam(headerSource, name, val, dst)
 	}
 	return b
 }
@@ -138,7 +220,9 @@ func (b *Binder) ParseMultipartForm(maxMemory int64) *Binder {
 	if maxMemory == 0 {
 		maxMemory = 32 << 20
 	}
-	b.err = b.r.ParseMultipartForm(maxMemory)
+	if err := b.r.ParseMultipartForm(maxMemory); err != nil {
+		b.err = bodyError(err)
+	}
 	return b
 }
 
@@ -148,12 +232,13 @@ func (b *Binder) FormValue(name string, dst any) *Binder {
 		return b
 	}
 	if val := b.r.PostFormValue(name); val != "" {
-		b.err = bindText(val, dst)
+		b.err = bindParam(formSource, name, val, dst)
 	}
 	return b
 }
 
 // UploadedFile reads the named file from a parsed multipart form as a *media.Media.
+// The file is buffered in memory; use Uploads to stream files instead.
 // ParseMultipartForm must be called first. Returns (nil, false) if the file is absent.
 func (b *Binder) UploadedFile(name string, svc media.MediaService) (*media.Media, bool) {
 	if b.err != nil {
@@ -164,11 +249,15 @@ func (b *Binder) UploadedFile(name string, svc media.MediaService) (*media.Media
 		return nil, false
 	}
 	if err != nil {
-		b.err = err
+		b.err = bodyError(err)
 		return nil, false
 	}
+	defer file.Close()
 	var buf bytes.Buffer
-	io.Copy(&buf, file)
+	if _, err := io.Copy(&buf, file); err != nil {
+		b.err = bodyError(err)
+		return nil, false
+	}
 	return media.New(&buf, svc), true
 }
 
@@ -203,6 +292,50 @@ func ParsePagination(r *http.Request) *prim.Pagination {
 	})
 }
 
+// CursorPagination parses cursor/limit query params into dst, for keyset
+// pagination. A cursor that was not issued by this service, or a limit out of
+// 1 to MaxCursorLimit, fails with INVALID_QUERY_PARAM.
+func (b *Binder) CursorPagination(dst *prim.CursorPagination) *Binder {
+	if b.err != nil {
+		return b
+	}
+	if dst == nil {
+		b.err = fmt.Errorf("httpserver: CursorPagination dst must be non-nil")
+		return b
+	}
+	pag, err := ParseCursorPagination(b.r)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	*dst = *pag
+	return b
+}
+
+// ParseCursorPagination parses cursor/limit query params and returns a
+// CursorPagination value. An absent cursor reads the first page.
+func ParseCursorPagination(r *http.Request) (*prim.CursorPagination, error) {
+	q := r.URL.Query()
+	var limit int
+	if v := q.Get("limit"); v != "" {
+		if err := bindParam(querySource, "limit", v, &limit); err != nil {
+			return nil, err
+		}
+		if limit <= 0 || limit > MaxCursorLimit {
+			return nil, paramError(querySource, "limit", fmt.Sprintf("must be between 1 and %d", MaxCursorLimit))
+		}
+	}
+	var cursor *prim.Cursor
+	if v := q.Get("cursor"); v != "" {
+		c, err := prim.DecodeCursor(v)
+		if err != nil {
+			return nil, paramError(querySource, "cursor", "invalid cursor")
+		}
+		cursor = c
+	}
+	return prim.NewCursorPagination(cursor, limit), nil
+}
+
 // Market parses the market derived from the x-timezone request header into dst.
 func (b *Binder) Market(dst *prim.Market) *Binder {
 	if b.err != nil {
@@ -214,7 +347,11 @@ func (b *Binder) Market(dst *prim.Market) *Binder {
 	}
 	market, err := ParseMarket(b.r)
 	if err != nil {
-		b.err = err
+		detail := "required"
+		if tz := b.r.Header.Get("x-timezone"); tz != "" {
+			detail = invalidValue(tz)
+		}
+		b.err = paramError(headerSource, "x-timezone", detail)
 		return b
 	}
 	*dst = market
@@ -237,7 +374,7 @@ func (b *Binder) Languages(dst *[]string) *Binder {
 	}
 	langs, err := ParseLanguages(b.r)
 	if err != nil {
-		b.err = err
+		b.err = paramError(headerSource, "Accept-Language", invalidValue(b.r.Header.Get("Accept-Language")))
 		return b
 	}
 	*dst = langs
@@ -308,6 +445,15 @@ func ContextValue[T any](r *http.Request, key any) (T, bool) {
 	return typed, ok
 }
 
+// unsupportedTypeError reports a bind destination of a type bindText cannot set.
+type unsupportedTypeError struct {
+	typ reflect.Type
+}
+
+func (e *unsupportedTypeError) Error() string {
+	return fmt.Sprintf("httpserver: unsupported bind destination type %s", e.typ)
+}
+
 // bindText assigns the string s to dst.
 // Supported types: *string, all integer/float/bool pointer kinds, and encoding.TextUnmarshaler.
 func bindText(s string, dst any) error {
@@ -404,7 +550,7 @@ func bindText(s string, dst any) error {
 			rv.Elem().SetString(s)
 			return nil
 		}
-		return fmt.Errorf("httpserver: unsupported bind destination type %T", dst)
+		return &unsupportedTypeError{reflect.TypeOf(dst)}
 	}
 	return nil
 }
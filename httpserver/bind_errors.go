@@ -0,0 +1,183 @@
+package httpserver
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+// paramSource describes where a bound value comes from, for error reporting.
+type paramSource struct {
+	code  apperr.Code
+	label string
+}
+
+var (
+	pathSource   = paramSource{apperr.InvalidPathParam, "path param"}
+	querySource  = paramSource{apperr.InvalidQueryParam, "query param"}
+	headerSource = paramSource{apperr.InvalidHeader, "header"}
+	formSource   = paramSource{apperr.InvalidFormValue, "form value"}
+)
+
+// paramError returns the Request error for an invalid value of the named
+// param. The param is reported in the message and as the only field detail.
+func paramError(src paramSource, name, detail string) error {
+	return apperr.Wrap(
+		apperr.NewMapError(map[string]string{name: detail}),
+		apperr.Request, src.code, fmt.Sprintf("invalid %s %q", src.label, name),
+		apperr.WithMeta("param", name),
+	)
+}
+
+// bindParam binds val to dst, turning a parse failure into a paramError.
+func bindParam(src paramSource, name, val string, dst any) error {
+	err := bindText(val, dst)
+	var unsupported *unsupportedTypeError
+	if err == nil || errors.As(err, &unsupported) {
+		return err
+	}
+	return paramError(src, name, invalidValue(val))
+}
+
+func invalidValue(val string) string {
+	return fmt.Sprintf("invalid value %q", val)
+}
+
+// checkContentType returns an UNSUPPORTED_MEDIA_TYPE error (415) when the
+// request declares a Content-Type not accepted by match.
+func checkContentType(r *http.Request, expected string, match func(mediaType string) bool) error {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return nil
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err == nil && match(mt) {
+		return nil
+	}
+	return apperr.New(apperr.Request, apperr.UnsupportedMediaType,
+		fmt.Sprintf("unsupported content type %q, expected %s", ct, expected),
+		apperr.WithStatus(http.StatusUnsupportedMediaType),
+	)
+}
+
+func isJSONMediaType(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// bodyError maps a failure to read or parse the request body.
+func bodyError(err error) error {
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytes):
+		return apperr.Wrap(err, apperr.Request, apperr.BodyTooLarge,
+			fmt.Sprintf("request body exceeds %d bytes", maxBytes.Limit),
+			apperr.WithStatus(http.StatusRequestEntityTooLarge),
+		)
+	case errors.Is(err, multipart.ErrMessageTooLarge):
+		return apperr.Wrap(err, apperr.Request, apperr.BodyTooLarge, "multipart form is too large",
+			apperr.WithStatus(http.StatusRequestEntityTooLarge),
+		)
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		return apperr.Wrap(err, apperr.Request, apperr.UnsupportedMediaType, "expected a multipart/form-data body",
+			apperr.WithStatus(http.StatusUnsupportedMediaType),
+		)
+	}
+	return apperr.Wrap(err, apperr.Request, apperr.BadRequest, "could not read request body")
+}
+
+// decodeError maps an error of json.Decoder.Decode, which may come from
+// reading the body as well as from parsing it.
+func decodeError(err error) error {
+	var (
+		maxBytes *http.MaxBytesError
+		corrupt  flate.CorruptInputError
+	)
+	switch {
+	case errors.As(err, &maxBytes), errors.As(err, &corrupt),
+		errors.Is(err, gzip.ErrHeader), errors.Is(err, gzip.ErrChecksum),
+		errors.Is(err, zlib.ErrHeader), errors.Is(err, zlib.ErrChecksum):
+		return bodyError(err)
+	case errors.Is(err, io.EOF):
+		return apperr.Wrap(err, apperr.Request, apperr.MalformedJSON, "empty JSON body")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperr.Wrap(err, apperr.Request, apperr.MalformedJSON, "unexpected end of JSON input")
+	}
+
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		if name, unquoteErr := strconv.Unquote(field); unquoteErr == nil {
+			return apperr.Wrap(
+				apperr.NewMapError(map[string]string{name: "unknown field"}),
+				apperr.Request, apperr.MalformedJSON, fmt.Sprintf("unknown field %q", name),
+			)
+		}
+	}
+	return jsonError(err)
+}
+
+// trailingDataError reports data found after the JSON value, unless reading
+// it failed for another reason.
+func trailingDataError(err error, offset int64) error {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return bodyError(err)
+	}
+	return apperr.New(apperr.Request, apperr.MalformedJSON,
+		fmt.Sprintf("unexpected data after JSON value at offset %d", offset),
+		apperr.WithMeta("offset", offset),
+	)
+}
+
+// jsonError maps a json.Unmarshal error to a MALFORMED_JSON error reporting
+// where in the body decoding failed.
+func jsonError(err error) error {
+	var (
+		syntaxErr  *json.SyntaxError
+		typeErr    *json.UnmarshalTypeError
+		invalidErr *json.InvalidUnmarshalError
+		appErr     *apperr.AppError
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &syntaxErr):
+		return apperr.Wrap(err, apperr.Request, apperr.MalformedJSON,
+			fmt.Sprintf("malformed JSON at offset %d: %s", syntaxErr.Offset, syntaxErr),
+			apperr.WithMeta("offset", syntaxErr.Offset),
+		)
+	case errors.As(err, &typeErr):
+		path := jsonPath(typeErr.Field)
+		return apperr.Wrap(
+			apperr.NewMapError(map[string]string{path: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)}),
+			apperr.Request, apperr.MalformedJSON,
+			fmt.Sprintf("invalid JSON value at %s (offset %d)", path, typeErr.Offset),
+			apperr.WithMeta("path", path), apperr.WithMeta("offset", typeErr.Offset),
+		)
+	case errors.As(err, &invalidErr):
+		// The destination is not a pointer: a bug, not a bad request.
+		return err
+	case errors.As(err, &appErr):
+		// Raised by an UnmarshalJSON method, which knows best.
+		return err
+	}
+	return apperr.Wrap(err, apperr.Request, apperr.MalformedJSON, "invalid JSON body")
+}
+
+// jsonPath returns the JSONPath of a dotted json.UnmarshalTypeError field,
+// e.g. "$.items.0.price".
+func jsonPath(field string) string {
+	if field == "" {
+		return "$"
+	}
+	return "$." + field
+}
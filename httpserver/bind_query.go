@@ -0,0 +1,162 @@
+package httpserver
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+)
+
+// queryField is a field of a SortAndFilter spec.
+type queryField struct {
+	column   string
+	sortable bool
+	ops      []prim.FilterOp
+	typ      reflect.Type
+}
+
+// SortAndFilter parses the sort query param and the filters on the fields
+// declared by spec, a struct (or pointer to one) used as a whitelist:
+//
+//	type ListQuery struct {
+//		CreatedAt time.Time `sort:"createdAt" filter:"createdAt" ops:"gte,lte" column:"created_at"`
+//		Status    string    `filter:"status" ops:"eq,in"`
+//		Price     float64   `sort:"price" filter:"price" ops:"gte,lte"`
+//	}
+//
+// allows ?sort=-createdAt,price&status=in:active,pending&price=gte:100. ops
+// defaults to eq and column to the public name. Filter values are converted
+// to the type of their spec field. Fields, operators or values not allowed
+// are all reported together as INVALID_QUERY_PARAM, keyed by param.
+func (b *Binder) SortAndFilter(spec any, sort *prim.Sort, filters *prim.Filters) *Binder {
+	if b.err != nil {
+		return b
+	}
+	if sort == nil || filters == nil {
+		b.err = fmt.Errorf("httpserver: SortAndFilter sort and filters must be non-nil")
+		return b
+	}
+	fields, err := queryFieldsOf(spec)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	errs := &fieldErrors{details: make(map[string]error)}
+	q := b.r.URL.Query()
+
+	if str := q.Get("sort"); str != "" {
+		parsed, err := prim.ParseSort(str)
+		if err != nil {
+			errs.add(querySource, "sort", err)
+		}
+		for i, sf := range parsed {
+			field, ok := fields[sf.Field]
+			if !ok || !field.sortable {
+				errs.add(querySource, "sort", fmt.Errorf("cannot sort by %q", sf.Field))
+				break
+			}
+			parsed[i].Column = field.column
+		}
+		*sort = parsed
+	}
+
+	var parsed prim.Filters
+	for name, field := range fields {
+		if field.ops == nil {
+			continue
+		}
+		for _, expr := range q[name] {
+			filter, err := parseQueryFilter(name, expr, field)
+			if err != nil {
+				errs.add(querySource, name, err)
+				break
+			}
+			parsed = append(parsed, filter)
+		}
+	}
+	// Map iteration is random; keep filters in a stable order.
+	slices.SortStableFunc(parsed, func(a, b prim.Filter) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	*filters = parsed
+
+	if len(errs.details) > 0 {
+		b.err = errs.err()
+	}
+	return b
+}
+
+func parseQueryFilter(name, expr string, field queryField) (prim.Filter, error) {
+	filter, err := prim.ParseFilter(name, expr)
+	if err != nil {
+		return filter, err
+	}
+	if !slices.Contains(field.ops, filter.Op) {
+		return filter, fmt.Errorf("operator %q is not allowed", filter.Op)
+	}
+	filter.Column = field.column
+
+	typ := field.typ
+	if filter.Op == prim.OpExist {
+		typ = reflect.TypeFor[bool]()
+	}
+	for i, raw := range filter.Values {
+		v := reflect.New(typ).Elem()
+		if err := bindValue(raw.(string), v); err != nil {
+			return filter, err
+		}
+		filter.Values[i] = v.Interface()
+	}
+	return filter, nil
+}
+
+// queryFieldsOf reads the sort and filter declarations of spec, by public name.
+func queryFieldsOf(spec any) (map[string]queryField, error) {
+	t := reflect.TypeOf(spec)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("httpserver: SortAndFilter spec must be a struct, got %T", spec)
+	}
+
+	fields := make(map[string]queryField)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		sortName := sf.Tag.Get("sort")
+		filterName := sf.Tag.Get("filter")
+		if sortName == "" && filterName == "" {
+			continue
+		}
+		if sortName != "" && filterName != "" && sortName != filterName {
+			return nil, fmt.Errorf("httpserver: field %s has different sort and filter names", sf.Name)
+		}
+
+		name := sortName
+		if name == "" {
+			name = filterName
+		}
+		field := queryField{
+			column:   sf.Tag.Get("column"),
+			sortable: sortName != "",
+			typ:      sf.Type,
+		}
+		if field.column == "" {
+			field.column = name
+		}
+		if filterName != "" {
+			field.ops = []prim.FilterOp{prim.OpEq}
+			if ops := sf.Tag.Get("ops"); ops != "" {
+				field.ops = nil
+				for _, op := range strings.Split(ops, ",") {
+					field.ops = append(field.ops, prim.FilterOp(strings.TrimSpace(op)))
+				}
+			}
+		}
+		fields[name] = field
+	}
+	return fields, nil
+}
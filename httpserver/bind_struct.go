@@ -0,0 +1,251 @@
+package httpserver
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/validator"
+)
+
+// bindSources are the struct tags read by Binder.Struct, in order of precedence.
+var bindSources = []string{"path", "query", "header", "form", "ctx"}
+
+var tagSources = map[string]paramSource{
+	"path":   pathSource,
+	"query":  querySource,
+	"header": headerSource,
+	"form":   formSource,
+}
+
+var contextKeys sync.Map
+
+func init() {
+	RegisterContextKey("actor", ActorLogKey)
+}
+
+// RegisterContextKey names a context key so that Binder.Struct can bind its
+// value with a `ctx:"name"` tag. "actor" is registered for ActorLogKey.
+func RegisterContextKey(name string, key any) {
+	contextKeys.Store(name, key)
+}
+
+// Struct binds the exported fields of the struct pointed to by dst from their
+// tags, then validates it with validator.Validate:
+//
+//	type Input struct {
+//		ID     string   `path:"id"`
+//		Limit  int      `query:"limit" default:"20"`
+//		Tags   []string `query:"tag"`
+//		Token  string   `header:"x-token" validate:"required"`
+//		Name   string   `form:"name"`
+//		Actor  Actor    `ctx:"actor"`
+//	}
+//
+// Slice fields take every value of a repeated query param, header or form
+// field. default is used when the value is absent, split on commas for slices.
+// All binding failures are reported together as a field map keyed by the
+// param name, with the code of their source (see Err); validation only runs if
+// binding succeeded.
+func (b *Binder) Struct(dst any) *Binder {
+	if b.err != nil {
+		return b
+	}
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		b.err = fmt.Errorf("httpserver: Struct dst must be a non-nil pointer to a struct, got %T", dst)
+		return b
+	}
+
+	errs := &fieldErrors{details: make(map[string]error)}
+	if err := b.bindFields(dv.Elem(), errs); err != nil {
+		b.err = err
+		return b
+	}
+	if len(errs.details) > 0 {
+		b.err = errs.err()
+		return b
+	}
+
+	b.err = validator.Validate(dst)
+	return b
+}
+
+// bindFields binds the tagged fields of v, recording value errors in errs.
+// The returned error is for misuse, such as an unsupported field type.
+func (b *Binder) bindFields(v reflect.Value, errs *fieldErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		source, name := bindTag(field)
+		if source == "" {
+			if field.Anonymous && fv.Kind() == reflect.Struct {
+				if err := b.bindFields(fv, errs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if source == "ctx" {
+			if err := b.bindContextField(name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		vals, err := b.sourceValues(source, name)
+		if err != nil {
+			return err
+		}
+		if len(vals) == 0 {
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			vals = []string{def}
+			if fv.Kind() == reflect.Slice {
+				vals = strings.Split(def, ",")
+			}
+		}
+
+		if err := bindValues(vals, fv); err != nil {
+			var unsupported *unsupportedTypeError
+			if errors.As(err, &unsupported) {
+				return err
+			}
+			errs.add(tagSources[source], name, err)
+		}
+	}
+	return nil
+}
+
+// fieldErrors collects the binding failures of Struct. code is the one of
+// their source, or BAD_REQUEST when they come from several sources.
+type fieldErrors struct {
+	details map[string]error
+	code    apperr.Code
+}
+
+func (e *fieldErrors) err() error {
+	return apperr.Wrap(apperr.NewMapErrorFrom(e.details), apperr.Request, e.code, "invalid parameter(s)")
+}
+
+func (e *fieldErrors) add(src paramSource, name string, err error) {
+	e.details[name] = err
+	if e.code == "" {
+		e.code = src.code
+	} else if e.code != src.code {
+		e.code = apperr.BadRequest
+	}
+}
+
+// bindTag returns the first bind source tag of field and its name.
+func bindTag(field reflect.StructField) (source, name string) {
+	for _, source := range bindSources {
+		if name, ok := field.Tag.Lookup(source); ok && name != "" && name != "-" {
+			return source, name
+		}
+	}
+	return "", ""
+}
+
+func (b *Binder) sourceValues(source, name string) ([]string, error) {
+	switch source {
+	case "path":
+		if val := chi.URLParam(b.r, name); val != "" {
+			return []string{val}, nil
+		}
+		return nil, nil
+	case "query":
+		return b.r.URL.Query()[name], nil
+	case "header":
+		return b.r.Header.Values(name), nil
+	case "form":
+		if err := b.parseForm(); err != nil {
+			return nil, bodyError(err)
+		}
+		return b.r.PostForm[name], nil
+	}
+	return nil, nil
+}
+
+// parseForm parses a URL-encoded or multipart body once.
+func (b *Binder) parseForm() error {
+	if b.r.PostForm != nil {
+		return nil
+	}
+	mt, _, _ := mime.ParseMediaType(b.r.Header.Get("Content-Type"))
+	if mt == "multipart/form-data" {
+		return b.r.ParseMultipartForm(32 << 20)
+	}
+	return b.r.ParseForm()
+}
+
+func (b *Binder) bindContextField(name string, fv reflect.Value) error {
+	key, ok := contextKeys.Load(name)
+	if !ok {
+		return fmt.Errorf("httpserver: context key %q is not registered", name)
+	}
+	val := b.r.Context().Value(key)
+	if val == nil {
+		return nil
+	}
+	sv := reflect.ValueOf(val)
+	if !sv.Type().AssignableTo(fv.Type()) {
+		return fmt.Errorf("httpserver: cannot assign context value of type %T to %s", val, fv.Type())
+	}
+	fv.Set(sv)
+	return nil
+}
+
+// bindValues assigns vals to fv: all of them to a slice, the first one otherwise.
+func bindValues(vals []string, fv reflect.Value) error {
+	if fv.Kind() == reflect.Slice && !isTextUnmarshaler(fv) {
+		slice := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := bindValue(strings.TrimSpace(val), slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return bindValue(vals[0], fv)
+}
+
+func bindValue(s string, fv reflect.Value) error {
+	if s == "" {
+		return nil
+	}
+	if fv.Kind() == reflect.Pointer && !isTextUnmarshaler(fv) {
+		elem := reflect.New(fv.Type().Elem())
+		if err := bindValue(s, elem.Elem()); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+
+	err := bindText(s, fv.Addr().Interface())
+	var unsupported *unsupportedTypeError
+	if err != nil && !errors.As(err, &unsupported) {
+		return errors.New(invalidValue(s))
+	}
+	return err
+}
+
+func isTextUnmarshaler(fv reflect.Value) bool {
+	return fv.Addr().Type().Implements(reflect.TypeFor[encoding.TextUnmarshaler]())
+}
@@ -2,14 +2,22 @@ package httpserver_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/kgjoner/cornucopia/v3/apperr"
 	"github.com/kgjoner/cornucopia/v3/httpserver"
 	"github.com/kgjoner/cornucopia/v3/media"
 	"github.com/kgjoner/cornucopia/v3/prim"
@@ -401,3 +409,417 @@ func TestBindTextUnmarshaler(t *testing.T) {
 
 // Ensure unused imports from media package don't slip through.
 var _ media.MediaService = (*mockMediaService)(nil)
+
+type structInput struct {
+	ID    int      `path:"id"`
+	Limit int      `query:"limit" default:"20"`
+	Tags  []string `query:"tag"`
+	Sizes []int    `query:"size" default:"1,2"`
+	Since *int64   `query:"since"`
+	Token string   `header:"x-token" validate:"required"`
+	Name  string   `form:"name"`
+	Actor string   `ctx:"actor"`
+}
+
+func TestStructBinding(t *testing.T) {
+	form := strings.NewReader("name=Ann")
+	req := httptest.NewRequest("POST", "/items/7?tag=a&tag=b&since=100", form)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Token", "secret")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "7")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, httpserver.ActorLogKey, "user-1")
+	req = req.WithContext(ctx)
+
+	var in structInput
+	if err := httpserver.Bind(req).Struct(&in).Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	since := int64(100)
+	want := structInput{
+		ID:    7,
+		Limit: 20,
+		Tags:  []string{"a", "b"},
+		Sizes: []int{1, 2},
+		Since: &since,
+		Token: "secret",
+		Name:  "Ann",
+		Actor: "user-1",
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("Expected %+v, got %+v", want, in)
+	}
+}
+
+func TestStructBindingCollectsAllErrors(t *testing.T) {
+	req := httptest.NewRequest("GET", "/items?limit=ten&size=1&size=x", nil)
+	req.Header.Set("X-Token", "secret")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "abc")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	var in structInput
+	err := httpserver.Bind(req).Struct(&in).Err()
+
+	var e *apperr.AppError
+	if !errors.As(err, &e) || e.Kind != apperr.Request || e.Code != apperr.BadRequest {
+		t.Fatalf("Expected a Request BAD_REQUEST AppError, got %v", err)
+	}
+	want := map[string]string{
+		"id":    `invalid value "abc"`,
+		"limit": `invalid value "ten"`,
+		"size":  `invalid value "x"`,
+	}
+	if details := e.PublicDetails().Details(); !reflect.DeepEqual(details, want) {
+		t.Errorf("Expected %v, got %v", want, details)
+	}
+}
+
+func TestStructBindingValidates(t *testing.T) {
+	req := httptest.NewRequest("GET", "/items", nil)
+
+	var in structInput
+	err := httpserver.Bind(req).Struct(&in).Err()
+
+	var e *apperr.AppError
+	if !errors.As(err, &e) || e.Kind != apperr.Validation {
+		t.Fatalf("Expected a Validation AppError, got %v", err)
+	}
+	if _, ok := e.PublicDetails().Details()["Token"]; !ok {
+		t.Errorf("Expected Token to be reported, got %v", err)
+	}
+}
+
+func bindErrorResponse(t *testing.T, err error) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	httpserver.Error(err, rec, httptest.NewRequest("GET", "/", nil))
+
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Expected JSON body, got error: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestBindErrors(t *testing.T) {
+	pathReq := httptest.NewRequest("GET", "/items/abc", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "abc")
+	pathReq = pathReq.WithContext(context.WithValue(pathReq.Context(), chi.RouteCtxKey, rctx))
+
+	xmlReq := httptest.NewRequest("POST", "/", strings.NewReader("<a/>"))
+	xmlReq.Header.Set("Content-Type", "application/xml")
+
+	largeReq := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"a long name"}`))
+	largeReq.Body = http.MaxBytesReader(httptest.NewRecorder(), largeReq.Body, 4)
+
+	var id, limit int
+	var token int
+	var body struct {
+		Items []struct {
+			Price float64 `json:"price"`
+		} `json:"items"`
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    apperr.Code
+		message string
+		details map[string]any
+	}{
+		{
+			name:    "path param",
+			err:     httpserver.Bind(pathReq).PathParam("id", &id).Err(),
+			status:  http.StatusBadRequest,
+			code:    apperr.InvalidPathParam,
+			message: `invalid path param "id"`,
+			details: map[string]any{"id": `invalid value "abc"`},
+		},
+		{
+			name:    "query param",
+			err:     httpserver.Bind(httptest.NewRequest("GET", "/?limit=ten", nil)).QueryParam("limit", &limit).Err(),
+			status:  http.StatusBadRequest,
+			code:    apperr.InvalidQueryParam,
+			message: `invalid query param "limit"`,
+			details: map[string]any{"limit": `invalid value "ten"`},
+		},
+		{
+			name: "header",
+			err: func() error {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("X-Count", "x")
+				return httpserver.Bind(req).Header("X-Count", &token).Err()
+			}(),
+			status:  http.StatusBadRequest,
+			code:    apperr.InvalidHeader,
+			message: `invalid header "X-Count"`,
+			details: map[string]any{"X-Count": `invalid value "x"`},
+		},
+		{
+			name: "market",
+			err: func() error {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("x-timezone", "Mars/Olympus")
+				var market prim.Market
+				return httpserver.Bind(req).Market(&market).Err()
+			}(),
+			status:  http.StatusBadRequest,
+			code:    apperr.InvalidHeader,
+			message: `invalid header "x-timezone"`,
+			details: map[string]any{"x-timezone": `invalid value "Mars/Olympus"`},
+		},
+		{
+			name:    "malformed JSON",
+			err:     httpserver.Bind(httptest.NewRequest("POST", "/", strings.NewReader(`{"items": [}`))).JSONBody(&body).Err(),
+			status:  http.StatusBadRequest,
+			code:    apperr.MalformedJSON,
+			message: "malformed JSON at offset 12: invalid character '}' looking for beginning of value",
+		},
+		{
+			name:    "JSON type mismatch",
+			err:     httpserver.Bind(httptest.NewRequest("POST", "/", strings.NewReader(`{"items": [{"price": "free"}]}`))).JSONBody(&body).Err(),
+			status:  http.StatusBadRequest,
+			code:    apperr.MalformedJSON,
+			message: "invalid JSON value at $.items.0.price (offset 27)",
+			details: map[string]any{"$.items.0.price": "expected float64, got string"},
+		},
+		{
+			name:    "unsupported media type",
+			err:     httpserver.Bind(xmlReq).JSONBody(&body).Err(),
+			status:  http.StatusUnsupportedMediaType,
+			code:    apperr.UnsupportedMediaType,
+			message: `unsupported content type "application/xml", expected application/json`,
+		},
+		{
+			name:    "body too large",
+			err:     httpserver.Bind(largeReq).JSONBody(&body).Err(),
+			status:  http.StatusRequestEntityTooLarge,
+			code:    apperr.BodyTooLarge,
+			message: "request body exceeds 4 bytes",
+		},
+		{
+			name:    "not multipart",
+			err:     httpserver.Bind(httptest.NewRequest("POST", "/", nil)).ParseMultipartForm(0).Err(),
+			status:  http.StatusUnsupportedMediaType,
+			code:    apperr.UnsupportedMediaType,
+			message: "expected a multipart/form-data body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, res := bindErrorResponse(t, tt.err)
+
+			if status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, status)
+			}
+			if res["kind"] != string(apperr.Request) || res["code"] != string(tt.code) {
+				t.Errorf("Expected Request %s, got %v", tt.code, res)
+			}
+			if res["message"] != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, res["message"])
+			}
+			if tt.details != nil && !reflect.DeepEqual(res["details"], tt.details) {
+				t.Errorf("Expected details %v, got %v", tt.details, res["details"])
+			}
+		})
+	}
+}
+
+func compressed(t *testing.T, encoding, s string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	}
+	w.Write([]byte(s))
+	w.Close()
+	return &buf
+}
+
+func TestJSONBodyDecompresses(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", compressed(t, encoding, `{"name":"Ann"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", encoding)
+
+			var body struct{ Name string }
+			if err := httpserver.Bind(req).JSONBody(&body).Err(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if body.Name != "Ann" {
+				t.Errorf("Expected name 'Ann', got %q", body.Name)
+			}
+		})
+	}
+}
+
+func TestJSONBodyStrictness(t *testing.T) {
+	type input struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name    string
+		bind    func(r *http.Request) *httpserver.Binder
+		req     func() *http.Request
+		status  int
+		code    apperr.Code
+		message string
+	}{
+		{
+			name: "unknown field",
+			bind: func(r *http.Request) *httpserver.Binder { return httpserver.Bind(r).DisallowUnknownFields() },
+			req: func() *http.Request {
+				return httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"a","admin":true}`))
+			},
+			status:  http.StatusBadRequest,
+			code:    apperr.MalformedJSON,
+			message: `unknown field "admin"`,
+		},
+		{
+			name: "trailing data",
+			bind: httpserver.Bind,
+			req: func() *http.Request {
+				return httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"a"} {"name":"b"}`))
+			},
+			status:  http.StatusBadRequest,
+			code:    apperr.MalformedJSON,
+			message: "unexpected data after JSON value at offset 12",
+		},
+		{
+			name:    "empty body",
+			bind:    httpserver.Bind,
+			req:     func() *http.Request { return httptest.NewRequest("POST", "/", nil) },
+			status:  http.StatusBadRequest,
+			code:    apperr.MalformedJSON,
+			message: "empty JSON body",
+		},
+		{
+			name: "decompressed size",
+			bind: func(r *http.Request) *httpserver.Binder { return httpserver.Bind(r).MaxBodySize(64) },
+			req: func() *http.Request {
+				req := httptest.NewRequest("POST", "/", compressed(t, "gzip", `{"name":"`+strings.Repeat("a", 1000)+`"}`))
+				req.Header.Set("Content-Encoding", "gzip")
+				return req
+			},
+			status:  http.StatusRequestEntityTooLarge,
+			code:    apperr.BodyTooLarge,
+			message: "request body exceeds 64 bytes",
+		},
+		{
+			name: "unsupported encoding",
+			bind: httpserver.Bind,
+			req: func() *http.Request {
+				req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+				req.Header.Set("Content-Encoding", "br")
+				return req
+			},
+			status:  http.StatusUnsupportedMediaType,
+			code:    apperr.UnsupportedMediaType,
+			message: `unsupported content encoding "br"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in input
+			status, res := bindErrorResponse(t, tt.bind(tt.req()).JSONBody(&in).Err())
+
+			if status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, status)
+			}
+			if res["code"] != string(tt.code) || res["message"] != tt.message {
+				t.Errorf("Expected %s %q, got %v", tt.code, tt.message, res)
+			}
+		})
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	cursor, _ := prim.NewCursor(int64(42))
+	req := httptest.NewRequest("GET", "/items?limit=5&cursor="+cursor.Encode(), nil)
+
+	var pag prim.CursorPagination
+	if err := httpserver.Bind(req).CursorPagination(&pag).Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var id int64
+	if pag.Limit != 5 || pag.Cursor == nil || pag.Cursor.Scan(&id) != nil || id != 42 {
+		t.Errorf("Unexpected pagination: %+v", pag)
+	}
+
+	first, err := httpserver.ParseCursorPagination(httptest.NewRequest("GET", "/items", nil))
+	if err != nil || first.Cursor != nil || first.Limit != 20 {
+		t.Errorf("Expected first page with default limit, got %+v, %v", first, err)
+	}
+
+	for _, query := range []string{"cursor=forged", "limit=-1", "limit=0", "limit=101"} {
+		_, err = httpserver.ParseCursorPagination(httptest.NewRequest("GET", "/items?"+query, nil))
+		var e *apperr.AppError
+		if !errors.As(err, &e) || e.Code != apperr.InvalidQueryParam {
+			t.Errorf("Expected INVALID_QUERY_PARAM for %s, got %v", query, err)
+		}
+	}
+}
+
+type listQuery struct {
+	CreatedAt time.Time `sort:"createdAt" filter:"createdAt" ops:"gte,lte" column:"created_at"`
+	Status    string    `filter:"status" ops:"eq,in"`
+	Price     float64   `sort:"price" filter:"price" ops:"gte,lte"`
+	Name      string    `sort:"name"`
+}
+
+func TestSortAndFilter(t *testing.T) {
+	req := httptest.NewRequest("GET", "/items?sort=-createdAt,price&status=in:active,pending&price=gte:100&price=lte:250.5", nil)
+
+	var sort prim.Sort
+	var filters prim.Filters
+	if err := httpserver.Bind(req).SortAndFilter(listQuery{}, &sort, &filters).Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	wantSort := prim.Sort{{Field: "createdAt", Column: "created_at", Desc: true}, {Field: "price", Column: "price"}}
+	if !reflect.DeepEqual(sort, wantSort) {
+		t.Errorf("Expected %+v, got %+v", wantSort, sort)
+	}
+	wantFilters := prim.Filters{
+		{Field: "price", Column: "price", Op: prim.OpGte, Values: []any{100.0}},
+		{Field: "price", Column: "price", Op: prim.OpLte, Values: []any{250.5}},
+		{Field: "status", Column: "status", Op: prim.OpIn, Values: []any{"active", "pending"}},
+	}
+	if !reflect.DeepEqual(filters, wantFilters) {
+		t.Errorf("Expected %+v, got %+v", wantFilters, filters)
+	}
+}
+
+func TestSortAndFilterWhitelist(t *testing.T) {
+	req := httptest.NewRequest("GET", "/items?sort=status&price=eq:1&createdAt=gte:yesterday", nil)
+
+	var sort prim.Sort
+	var filters prim.Filters
+	err := httpserver.Bind(req).SortAndFilter(&listQuery{}, &sort, &filters).Err()
+
+	var e *apperr.AppError
+	if !errors.As(err, &e) || e.Code != apperr.InvalidQueryParam {
+		t.Fatalf("Expected INVALID_QUERY_PARAM, got %v", err)
+	}
+	want := map[string]string{
+		"sort":      `cannot sort by "status"`,
+		"price":     `operator "eq" is not allowed`,
+		"createdAt": `invalid value "yesterday"`,
+	}
+	if details := e.PublicDetails().Details(); !reflect.DeepEqual(details, want) {
+		t.Errorf("Expected %v, got %v", want, details)
+	}
+}
@@ -0,0 +1,114 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/hash"
+)
+
+const etagsKey = ctxKey("etags")
+
+// ETags makes Success set a strong ETag, hashed from the encoded body, on the
+// 200 responses to GET and HEAD requests that have no explicit one, so that
+// clients can revalidate them with If-None-Match.
+func ETags(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), etagsKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SetETag sets the ETag Success sends from a version of the resource, such as
+// a revision number or an update timestamp, instead of hashing the body.
+func SetETag(w http.ResponseWriter, version string) {
+	w.Header().Set("ETag", formatETag(version))
+}
+
+// SetLastModified sets the Last-Modified header Success compares with
+// If-Modified-Since.
+func SetLastModified(w http.ResponseWriter, t time.Time) {
+	w.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// CheckIfMatch checks the If-Match header of an update against the current
+// version of the resource, as set by SetETag. It returns a Conflict error with
+// code PRECONDITION_FAILED (412) when the client's copy is outdated. A request
+// without If-Match passes.
+func CheckIfMatch(r *http.Request, version string) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	current := formatETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// If-Match uses the strong comparison: weak tags never match.
+		if tag == "*" || tag == current {
+			return nil
+		}
+	}
+	return apperr.New(apperr.Conflict, apperr.PreconditionFailed,
+		"the resource has changed since it was fetched",
+		apperr.WithStatus(http.StatusPreconditionFailed),
+	)
+}
+
+func formatETag(version string) string {
+	return `"` + strings.ReplaceAll(version, `"`, "") + `"`
+}
+
+// isCacheable reports whether Success must buffer the body to set an ETag or
+// to answer a conditional request.
+func isCacheable(r *http.Request, status int, h http.Header) bool {
+	if status != http.StatusOK || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	return etagsEnabled(r) || h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+func etagsEnabled(r *http.Request) bool {
+	enabled, _ := r.Context().Value(etagsKey).(bool)
+	return enabled
+}
+
+// writeCacheable writes body, or 304 Not Modified if the client has it already.
+func writeCacheable(w http.ResponseWriter, r *http.Request, status int, body []byte) {
+	h := w.Header()
+	if h.Get("ETag") == "" && etagsEnabled(r) {
+		h.Set("ETag", formatETag(hash.From(string(body))))
+	}
+
+	if notModified(r, h) {
+		h.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// notModified evaluates If-None-Match or, without it, If-Modified-Since.
+func notModified(r *http.Request, h http.Header) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := strings.TrimPrefix(h.Get("ETag"), "W/")
+		for _, tag := range strings.Split(inm, ",") {
+			// If-None-Match uses the weak comparison.
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || (etag != "" && tag == etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(h.Get("Last-Modified"))
+	return err == nil && !lastModified.After(ims)
+}
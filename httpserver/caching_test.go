@@ -0,0 +1,108 @@
+package httpserver_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+)
+
+func TestETags(t *testing.T) {
+	handler := httpserver.ETags(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpserver.Success(map[string]string{"name": "widget"}, w, r)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/items/1", nil))
+
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || len(etag) != 66 {
+		t.Fatalf("Expected 200 with a strong ETag, got %d %q", rec.Code, etag)
+	}
+
+	req := httptest.NewRequest("GET", "/items/1", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("Expected empty 304, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("ETag") != etag {
+		t.Errorf("Expected 304 to carry the ETag, got %q", rec.Header().Get("ETag"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/items/1", nil))
+	if rec.Header().Get("ETag") != "" {
+		t.Error("Expected no ETag on POST")
+	}
+}
+
+func TestExplicitValidators(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpserver.SetETag(w, "v7")
+		httpserver.SetLastModified(w, modified)
+		httpserver.Success("widget", w, r)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"no condition", "", "", http.StatusOK},
+		{"matching version", "If-None-Match", `"v7"`, http.StatusNotModified},
+		{"older version", "If-None-Match", `"v6"`, http.StatusOK},
+		{"not modified since", "If-Modified-Since", modified.Format(http.TimeFormat), http.StatusNotModified},
+		{"modified since", "If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/items/1", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected %d, got %d", tt.status, rec.Code)
+			}
+			if rec.Header().Get("ETag") != `"v7"` {
+				t.Errorf("Expected ETag \"v7\", got %q", rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/items/1", nil)
+	if err := httpserver.CheckIfMatch(req, "v7"); err != nil {
+		t.Errorf("Expected request without If-Match to pass, got %v", err)
+	}
+
+	req.Header.Set("If-Match", `"v6", "v7"`)
+	if err := httpserver.CheckIfMatch(req, "v7"); err != nil {
+		t.Errorf("Expected matching version to pass, got %v", err)
+	}
+
+	req.Header.Set("If-Match", `W/"v7"`)
+	err := httpserver.CheckIfMatch(req, "v7")
+	var e *apperr.AppError
+	if !errors.As(err, &e) || e.Code != apperr.PreconditionFailed {
+		t.Fatalf("Expected PRECONDITION_FAILED, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	httpserver.Error(err, rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder writes v in a given media type. v is the response as built by
+// Success, usually a {"data": ...} envelope or a struct with its own Data field.
+type Encoder func(w io.Writer, v any) error
+
+type registeredEncoder struct {
+	mediaType string
+	encode    Encoder
+}
+
+var (
+	encodersMu sync.RWMutex
+	// Ordered by server preference: the first match wins for wildcard ranges.
+	encoders = []registeredEncoder{
+		{"application/json", encodeJSON},
+		{"application/xml", encodeXML},
+		{"text/xml", encodeXML},
+		{"text/csv", encodeCSV},
+		{"application/msgpack", encodeMsgpack},
+		{"application/x-msgpack", encodeMsgpack},
+		{"application/vnd.msgpack", encodeMsgpack},
+	}
+)
+
+// RegisterEncoder sets the encoder Success uses for mediaType, replacing the
+// existing one or appending it with the lowest preference.
+func RegisterEncoder(mediaType string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	mediaType = strings.ToLower(mediaType)
+	for i, e := range encoders {
+		if e.mediaType == mediaType {
+			encoders[i].encode = enc
+			return
+		}
+	}
+	encoders = append(encoders, registeredEncoder{mediaType, enc})
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// negotiateEncoder picks the registered encoder that best matches the Accept
+// header of r. A missing Accept header means JSON. ok is false when nothing
+// acceptable is registered.
+func negotiateEncoder(r *http.Request) (mediaType string, enc Encoder, ok bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return encoders[0].mediaType, encoders[0].encode, true
+	}
+
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, exists := params["q"]; exists {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mt, q})
+	}
+
+	// Each encoder gets the q of its most specific matching range, so that
+	// "application/json;q=0" excludes JSON even with "*/*". Ties go to the
+	// server preference.
+	var (
+		best  *registeredEncoder
+		bestQ float64
+	)
+	for i, e := range encoders {
+		specificity, q := -1, 0.0
+		for _, ar := range ranges {
+			if s := rangeSpecificity(ar.mediaType); s > specificity && mediaRangeMatches(ar.mediaType, e.mediaType) {
+				specificity, q = s, ar.q
+			}
+		}
+		if q > bestQ {
+			best, bestQ = &encoders[i], q
+		}
+	}
+	if best == nil {
+		return "", nil, false
+	}
+	return best.mediaType, best.encode, true
+}
+
+// rangeSpecificity ranks */* below type/* below a full media type.
+func rangeSpecificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func mediaRangeMatches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if prefix, found := strings.CutSuffix(mediaRange, "/*"); found {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
+
+func contentTypeOf(mediaType string) string {
+	if strings.HasPrefix(mediaType, "text/") {
+		return mediaType + "; charset=utf-8"
+	}
+	return mediaType
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// encodeXML writes v under a <response> root element.
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "response"}})
+}
@@ -0,0 +1,183 @@
+package httpserver
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// encodeCSV writes the data of v as CSV, one row per element when it is a
+// slice. Structs are flattened into columns named after their json tags, with
+// nested structs joined by dots (e.g. "address.city"); maps use their keys;
+// other values go to a single "value" column. Slices and maps nested in a row
+// are written as JSON.
+func encodeCSV(w io.Writer, v any) error {
+	data := dataOf(reflect.ValueOf(v))
+
+	var items []reflect.Value
+	switch data.Kind() {
+	case reflect.Invalid:
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < data.Len(); i++ {
+			items = append(items, data.Index(i))
+		}
+	default:
+		items = append(items, data)
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+	rows := make([]map[string]string, len(items))
+	for i, item := range items {
+		rows[i] = map[string]string{}
+		flattenCSV("", item, rows[i], func(col string) {
+			if !seen[col] {
+				seen[col] = true
+				columns = append(columns, col)
+			}
+		})
+	}
+
+	cw := csv.NewWriter(w)
+	if len(columns) > 0 {
+		cw.Write(columns)
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = row[col]
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// dataOf returns the Data field of a response struct, or v itself.
+func dataOf(v reflect.Value) reflect.Value {
+	v = indirect(v)
+	if v.Kind() == reflect.Struct {
+		if field := v.FieldByName("Data"); field.IsValid() {
+			return indirect(field)
+		}
+	}
+	return v
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+var (
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+func flattenCSV(prefix string, v reflect.Value, row map[string]string, addColumn func(string)) {
+	set := func(col, val string) {
+		if col == "" {
+			col = "value"
+		}
+		addColumn(col)
+		row[col] = val
+	}
+
+	if v.IsValid() && (v.Type().Implements(textMarshalerType) || v.Type().Implements(jsonMarshalerType)) {
+		set(prefix, csvScalar(v))
+		return
+	}
+
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Invalid:
+		set(prefix, "")
+	case reflect.Struct:
+		if reflect.PointerTo(v.Type()).Implements(textMarshalerType) || reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+			set(prefix, csvScalar(v))
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if field.Anonymous && name == "" {
+				flattenCSV(prefix, v.Field(i), row, addColumn)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			flattenCSV(joinColumn(prefix, name), v.Field(i), row, addColumn)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			set(prefix, csvScalar(v))
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			flattenCSV(joinColumn(prefix, key.String()), v.MapIndex(key), row, addColumn)
+		}
+	default:
+		set(prefix, csvScalar(v))
+	}
+}
+
+func joinColumn(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// csvScalar formats a single cell, preferring text and JSON marshalers.
+func csvScalar(v reflect.Value) string {
+	if !v.IsValid() || ((v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil()) {
+		return ""
+	}
+	if v.CanInterface() {
+		if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+			text, err := tm.MarshalText()
+			if err == nil {
+				return string(text)
+			}
+		}
+	}
+
+	switch v = indirect(v); v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface())
+	}
+
+	raw, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
@@ -0,0 +1,150 @@
+package httpserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+	"github.com/kgjoner/cornucopia/v3/prim"
+)
+
+type encodeAddress struct {
+	City string `json:"city" xml:"city"`
+}
+
+type encodeUser struct {
+	Name    string        `json:"name" xml:"name"`
+	Age     int           `json:"age" xml:"age"`
+	Address encodeAddress `json:"address" xml:"address"`
+	secret  string
+}
+
+func successWithAccept(data any, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	httpserver.Success(data, rec, req)
+	return rec
+}
+
+func TestSuccessDefaultsToJSON(t *testing.T) {
+	rec := successWithAccept(map[string]int{"a": 1}, "")
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected application/json, got %q", ct)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"data":{"a":1}}` {
+		t.Errorf("Unexpected body: %s", body)
+	}
+	if vary := rec.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("Expected Vary: Accept, got %q", vary)
+	}
+}
+
+func TestSuccessEncodesXML(t *testing.T) {
+	rec := successWithAccept(encodeUser{Name: "Ann", Age: 30}, "application/xml")
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Expected application/xml, got %q", ct)
+	}
+	want := "<response><data><name>Ann</name><age>30</age><address><city></city></address></data></response>"
+	if body := rec.Body.String(); !strings.HasSuffix(body, want) {
+		t.Errorf("Expected body to end with %s, got %s", want, body)
+	}
+}
+
+func TestSuccessEncodingFailure(t *testing.T) {
+	rec := successWithAccept(map[string]any{"a": 1}, "application/xml")
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Expected JSON error body, got error: %v", err)
+	}
+	if body["kind"] != string(apperr.Internal) {
+		t.Errorf("Expected Internal error, got %v", body)
+	}
+}
+
+func TestSuccessEncodesCSV(t *testing.T) {
+	data := prim.NewPaginatedData(prim.Pagination{Limit: 2}, []encodeUser{
+		{Name: "Ann", Age: 30, Address: encodeAddress{City: "Lisbon"}},
+		{Name: "Bob, Jr.", Age: 41},
+	})
+	rec := successWithAccept(data, "text/csv, application/json;q=0.5")
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected text/csv, got %q", ct)
+	}
+	want := "name,age,address.city\nAnn,30,Lisbon\n\"Bob, Jr.\",41,\n"
+	if body := rec.Body.String(); body != want {
+		t.Errorf("Expected %q, got %q", want, body)
+	}
+}
+
+func TestSuccessEncodesMsgpack(t *testing.T) {
+	rec := successWithAccept([]any{"a", 1, true}, "application/msgpack")
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/msgpack" {
+		t.Errorf("Expected application/msgpack, got %q", ct)
+	}
+	// {"data": ["a", 1, true]}
+	want := []byte{0x81, 0xa4, 'd', 'a', 't', 'a', 0x93, 0xa1, 'a', 0x01, 0xc3}
+	if body := rec.Body.Bytes(); !bytes.Equal(body, want) {
+		t.Errorf("Expected % x, got % x", want, body)
+	}
+}
+
+func TestSuccessNotAcceptable(t *testing.T) {
+	rec := successWithAccept("ok", "image/png, application/json;q=0")
+
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("Expected 406, got %d", rec.Code)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Expected JSON body, got error: %v", err)
+	}
+	if body["code"] != string(apperr.NotAcceptable) {
+		t.Errorf("Unexpected body: %v", body)
+	}
+}
+
+func TestSuccessHonoursExclusions(t *testing.T) {
+	rec := successWithAccept("ok", "*/*;q=0.1, application/json;q=0")
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected a format other than the refused JSON, got %d %q", rec.Code, ct)
+	}
+
+	rec = successWithAccept("ok", "application/*;q=0.5, application/xml;q=1, text/*;q=0")
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Expected the most specific range to win, got %q", ct)
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	httpserver.RegisterEncoder("text/plain", func(w io.Writer, v any) error {
+		_, err := fmt.Fprintf(w, "%v", v)
+		return err
+	})
+
+	rec := successWithAccept("hello", "text/plain")
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Expected text/plain, got %q", ct)
+	}
+	if body := rec.Body.String(); body != "{hello}" {
+		t.Errorf("Expected {hello}, got %q", body)
+	}
+}
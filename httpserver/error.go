@@ -2,17 +2,19 @@ package httpserver
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/logger"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	log "github.com/sirupsen/logrus"
 )
 
 var (
+	// ErrCounters counts error responses by status on the global registry.
+	//
+	// Deprecated: use Metrics, which is registered on a caller-provided registry.
 	ErrCounters = map[int]prometheus.Counter{
 		401: promauto.NewCounter(prometheus.CounterOpts{
 			Name: "api_401_error_count",
@@ -53,65 +55,121 @@ var (
 	}
 )
 
+// Error logs the full error chain and writes the public part of err as the
+// response body. Status and log level come from, in order of precedence: the
+// error's explicit Status, the code registry (see apperr.Register) and its Kind.
+// Registered codes get their message localized using the Accept-Language header.
+// An apperr.MultiError is answered with the list of items and the overall Kind,
+// or the kind, code and message of an AppError wrapping it.
+// The body format is set with SetErrorRenderer.
 func Error(err error, w http.ResponseWriter, r *http.Request) {
+	status, public := resolveError(err, r)
+
+	if counter, ok := ErrCounters[status]; ok {
+		counter.Inc()
+	}
+
+	renderError(w, r, status, public)
+}
+
+// resolveError records and logs err, and returns the response status with the
+// public, localized error to be written.
+func resolveError(err error, r *http.Request) (int, error) {
 	var status int
-	var logLevel log.Level
+	var logLevel logger.Level
 	var e *apperr.AppError
-	if errors.As(err, &e) {
-		switch e.Kind {
-		case apperr.Unauthorized:
-			status = http.StatusUnauthorized
-			logLevel = log.WarnLevel
-		case apperr.Forbidden:
-			status = http.StatusForbidden
-			logLevel = log.WarnLevel
-		case apperr.Request:
-			status = http.StatusBadRequest
-			logLevel = log.WarnLevel
-		case apperr.Validation:
-			status = http.StatusUnprocessableEntity
-			logLevel = log.WarnLevel
-		case apperr.Conflict:
-			status = http.StatusConflict
-			logLevel = log.ErrorLevel
-		case apperr.External:
-			if e.Code == apperr.Unexpected {
-				status = http.StatusBadGateway
-				logLevel = log.ErrorLevel
-			} else {
-				status = http.StatusBadRequest
-				logLevel = log.WarnLevel
+	var multi *apperr.MultiError
+	if errors.As(err, &multi) {
+		e = multi.AppError()
+		if outer := outerAppError(err, multi); outer != nil {
+			e = outer
+			multi = multi.Summarized(outer)
+		}
+	} else {
+		errors.As(err, &e)
+	}
+
+	if e != nil {
+		status, logLevel = kindStatus(e)
+
+		if spec, ok := apperr.Lookup(e.Code); ok {
+			if spec.Status != 0 {
+				status = spec.Status
+			}
+			if spec.LogLevel != apperr.LogDefault {
+				logLevel = logLevelOf(spec.LogLevel)
 			}
-		default:
-			status = http.StatusInternalServerError
-			logLevel = log.ErrorLevel
+		}
+
+		if e.Status != 0 {
+			status = e.Status
 		}
 	} else {
 		if errors.Is(err, context.Canceled) {
 			err = apperr.NewRequestError(err.Error(), "CONTEXT_CANCELED")
 			status = 499
-			logLevel = log.WarnLevel
+			logLevel = logger.LevelWarn
 		} else if errors.Is(err, context.DeadlineExceeded) {
 			err = apperr.NewRequestError(err.Error(), "CONTEXT_TIMEOUT")
 			status = http.StatusRequestTimeout
-			logLevel = log.WarnLevel
+			logLevel = logger.LevelWarn
 		} else {
-			err = apperr.NewInternalError(err.Error())
+			err = apperr.Wrap(err, apperr.Internal, apperr.Unexpected, "unexpected error")
 			status = http.StatusInternalServerError
-			logLevel = log.ErrorLevel
+			logLevel = logger.LevelError
 		}
+		errors.As(err, &e)
 	}
 
 	if apperr.IsFatal(err) {
-		logLevel = log.FatalLevel
+		logLevel = logger.LevelFatal
+	}
+
+	if recordResponse(r, err, logLevel) {
+		logRequest(r, logLevel, err.Error(), err, nil)
 	}
 
-	counter := ErrCounters[status]
-	counter.Inc()
+	langs, _ := ParseLanguages(r)
+
+	if multi != nil {
+		return status, multi.Localized(langs...)
+	}
+	return status, e.Localized(langs...)
+}
 
-	NewLogger(r, err).Log(logLevel, err.Error())
+// outerAppError returns the first AppError of err's chain that wraps multi,
+// if any.
+func outerAppError(err error, multi *apperr.MultiError) *apperr.AppError {
+	for err != nil && err != error(multi) {
+		if e, ok := err.(*apperr.AppError); ok {
+			return e
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
 
-	w.WriteHeader(status)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(err)
+// kindStatus returns the default status and log level for the error's Kind.
+func kindStatus(e *apperr.AppError) (int, logger.Level) {
+	switch e.Kind {
+	case apperr.Unauthorized:
+		return http.StatusUnauthorized, logger.LevelWarn
+	case apperr.Forbidden:
+		return http.StatusForbidden, logger.LevelWarn
+	case apperr.Request:
+		return http.StatusBadRequest, logger.LevelWarn
+	case apperr.Validation:
+		return http.StatusUnprocessableEntity, logger.LevelWarn
+	case apperr.Conflict:
+		return http.StatusConflict, logger.LevelError
+	case apperr.TooManyRequests:
+		return http.StatusTooManyRequests, logger.LevelWarn
+	case apperr.External:
+		if e.Code == apperr.Unexpected {
+			return http.StatusBadGateway, logger.LevelError
+		}
+		return http.StatusBadRequest, logger.LevelWarn
+	default:
+		return http.StatusInternalServerError, logger.LevelError
+	}
 }
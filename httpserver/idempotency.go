@@ -0,0 +1,271 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/cache"
+	"github.com/kgjoner/cornucopia/v3/logger"
+)
+
+// IdempotencyHeader is the request header holding the client's idempotency key.
+const IdempotencyHeader = "Idempotency-Key"
+
+type IdempotencyOptions struct {
+	// Pool provides the store responses are kept in. Stores implementing
+	// cache.AtomicStore, such as memorydb and redisdb ones, make the in-flight
+	// check safe across concurrent requests.
+	Pool cache.Pool
+	// KeyGen builds the cache keys. Defaults to the "idempotency" prefix.
+	KeyGen *cache.KeyGen
+	// TTL is how long responses are replayed. Defaults to 24 hours.
+	TTL time.Duration
+	// LockTTL bounds how long a request is considered in flight, should its
+	// instance die before storing the response. Defaults to 1 minute.
+	LockTTL time.Duration
+	// Methods are the methods made idempotent. Defaults to POST and PATCH.
+	Methods []string
+	// MaxBodySize limits the request body, which is hashed as it streams to
+	// the handler. Larger bodies fail with BODY_TOO_LARGE (413). Zero leaves
+	// the limit to the handler, e.g. Binder.MaxBodySize.
+	MaxBodySize int64
+}
+
+// idempotentResponse is the cache entry of a key: in flight until Done.
+type idempotentResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Idempotency makes requests carrying an Idempotency-Key header safe to
+// retry. The first response for a key, scoped to the actor (see
+// ActorLogKey), is stored and replayed for later requests with the same key,
+// with an Idempotent-Replayed header. While the first request runs, others
+// with its key fail with REQUEST_IN_PROGRESS (409), and a key reused for
+// another method, path or body fails with IDEMPOTENCY_KEY_REUSED (422).
+// 5xx responses are not stored, so that they can be retried.
+func Idempotency(opts IdempotencyOptions) func(http.Handler) http.Handler {
+	if opts.KeyGen == nil {
+		opts.KeyGen = cache.NewKeyGen("idempotency")
+	}
+	if opts.TTL == 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.LockTTL == 0 {
+		opts.LockTTL = time.Minute
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get(IdempotencyHeader)
+			if idemKey == "" || !slices.Contains(opts.Methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(idemKey) > 255 {
+				Error(paramError(headerSource, IdempotencyHeader, "must be at most 255 characters"), w, r)
+				return
+			}
+
+			if opts.MaxBodySize > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, opts.MaxBodySize)
+			}
+			body := newFingerprintReader(r)
+
+			// The response must be stored even if the client gives up waiting
+			// for it, since that is when it retries.
+			store := opts.Pool.NewStore(context.WithoutCancel(r.Context()))
+			key := opts.KeyGen.Key(joinKeyParts(fmt.Sprint(r.Context().Value(ActorLogKey)), idemKey))
+
+			acquired, err := lockIdempotencyKey(store, key, opts.LockTTL)
+			if err != nil {
+				Error(err, w, r)
+				return
+			}
+			if !acquired {
+				replayIdempotent(store, key, body, w, r)
+				return
+			}
+
+			// The handler streams the body through the fingerprint.
+			r.Body = body
+			rec := &recordingWriter{ResponseWriter: w}
+			kept := false
+			defer func() {
+				// Free the key if the handler failed, so that it can be retried.
+				if !kept {
+					store.Clear(key)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 || rec.status >= 500 {
+				return
+			}
+			fingerprint, err := body.Sum()
+			if err != nil {
+				logRequest(r, logger.LevelError, "unable to read idempotent request body", err, nil)
+				return
+			}
+			kept = true
+			err = store.CacheJSON(key, idempotentResponse{
+				Fingerprint: fingerprint,
+				Done:        true,
+				Status:      rec.status,
+				Header:      rec.header,
+				Body:        rec.body.Bytes(),
+			}, opts.TTL)
+			if err != nil {
+				logRequest(r, logger.LevelError, "unable to store idempotent response", err, nil)
+			}
+		})
+	}
+}
+
+// joinKeyParts joins parts unambiguously, prefixing each with its length, so
+// that ("1", "23x") and ("12", "3x") hash differently.
+func joinKeyParts(parts ...string) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(strconv.Itoa(len(part)))
+		b.WriteByte(':')
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// fingerprintReader hashes the method, path and body of a request as the body
+// is read, so that it need not be buffered.
+type fingerprintReader struct {
+	body   io.ReadCloser
+	digest hash.Hash
+}
+
+func newFingerprintReader(r *http.Request) *fingerprintReader {
+	digest := sha256.New()
+	io.WriteString(digest, joinKeyParts(r.Method, r.URL.Path))
+	return &fingerprintReader{r.Body, digest}
+}
+
+func (f *fingerprintReader) Read(p []byte) (int, error) {
+	n, err := f.body.Read(p)
+	f.digest.Write(p[:n])
+	return n, err
+}
+
+// Close leaves the body open for Sum; the server closes it after the handler.
+func (f *fingerprintReader) Close() error {
+	return nil
+}
+
+// Sum reads what is left of the body and returns the fingerprint.
+func (f *fingerprintReader) Sum() (string, error) {
+	if _, err := io.Copy(io.Discard, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(f.digest.Sum(nil)), nil
+}
+
+// lockIdempotencyKey marks key as in flight, reporting whether it was free.
+func lockIdempotencyKey(store cache.Store, key string, ttl time.Duration) (bool, error) {
+	inFlight := idempotentResponse{}
+	if atomic, ok := store.(cache.AtomicStore); ok {
+		return atomic.AddJSON(key, inFlight, ttl)
+	}
+
+	var existing idempotentResponse
+	err := store.GetJSON(key, &existing)
+	if errors.Is(err, cache.ErrNil) {
+		return true, store.CacheJSON(key, inFlight, ttl)
+	}
+	return false, err
+}
+
+// replayIdempotent writes the stored response of key, or why it cannot.
+func replayIdempotent(store cache.Store, key string, body *fingerprintReader, w http.ResponseWriter, r *http.Request) {
+	var stored idempotentResponse
+	err := store.GetJSON(key, &stored)
+	switch {
+	case errors.Is(err, cache.ErrNil):
+		// The first request failed and freed the key in the meantime.
+		Error(apperr.Retryable(apperr.New(apperr.Conflict, apperr.RequestInProgress,
+			"a request with this idempotency key has just failed; retry it",
+			apperr.WithStatus(http.StatusConflict))), w, r)
+		return
+	case err != nil:
+		Error(err, w, r)
+		return
+	case !stored.Done:
+		Error(apperr.New(apperr.Conflict, apperr.RequestInProgress,
+			"a request with this idempotency key is in progress",
+			apperr.WithStatus(http.StatusConflict)), w, r)
+		return
+	}
+
+	fingerprint, err := body.Sum()
+	if err != nil {
+		Error(bodyError(err), w, r)
+		return
+	}
+	if stored.Fingerprint != fingerprint {
+		Error(apperr.New(apperr.Request, apperr.IdempotencyKeyReused,
+			"the idempotency key was already used for another request",
+			apperr.WithStatus(http.StatusUnprocessableEntity)), w, r)
+		return
+	}
+
+	h := w.Header()
+	for name, values := range stored.Header {
+		h[name] = values
+	}
+	h.Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// recordingWriter keeps a copy of the response it writes.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
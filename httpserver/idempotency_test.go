@@ -0,0 +1,223 @@
+package httpserver_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/cache"
+	"github.com/kgjoner/cornucopia/v3/cache/memorydb"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+)
+
+func idempotentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	req.Header.Set(httpserver.IdempotencyHeader, key)
+	return req
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	pool, _ := memorydb.NewPool()
+	var calls atomic.Int32
+	handler := httpserver.Idempotency(httpserver.IdempotencyOptions{Pool: pool})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Location", "/orders/1")
+		httpserver.Success(map[string]int32{"call": n}, w, r, http.StatusCreated)
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("key-1", `{"item":"book"}`))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, idempotentRequest("key-1", `{"item":"book"}`))
+
+	if calls.Load() != 1 {
+		t.Fatalf("Expected the handler to run once, ran %d times", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("Expected replayed 201 %q, got %d %q", first.Body.String(), second.Code, second.Body.String())
+	}
+	if second.Header().Get("Location") != "/orders/1" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected replayed headers, got %v", second.Header())
+	}
+
+	// Another actor does not share the key.
+	other := idempotentRequest("key-1", `{"item":"book"}`)
+	other = other.WithContext(context.WithValue(other.Context(), httpserver.ActorLogKey, "user-2"))
+	handler.ServeHTTP(httptest.NewRecorder(), other)
+	if calls.Load() != 2 {
+		t.Errorf("Expected the handler to run for another actor")
+	}
+
+	// Requests without a key are not affected.
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil))
+	if calls.Load() != 3 {
+		t.Errorf("Expected the handler to run without a key")
+	}
+}
+
+func TestIdempotencyRejectsReuseAndConcurrency(t *testing.T) {
+	pool, _ := memorydb.NewPool()
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := httpserver.Idempotency(httpserver.IdempotencyOptions{Pool: pool})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		httpserver.Success("ok", w, r)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-2", `{"item":"book"}`))
+		close(done)
+	}()
+	<-started
+
+	concurrent := httptest.NewRecorder()
+	handler.ServeHTTP(concurrent, idempotentRequest("key-2", `{"item":"book"}`))
+	if concurrent.Code != http.StatusConflict || !strings.Contains(concurrent.Body.String(), string(apperr.RequestInProgress)) {
+		t.Errorf("Expected 409 REQUEST_IN_PROGRESS, got %d %s", concurrent.Code, concurrent.Body.String())
+	}
+
+	close(release)
+	<-done
+
+	reused := httptest.NewRecorder()
+	handler.ServeHTTP(reused, idempotentRequest("key-2", `{"item":"pen"}`))
+	if reused.Code != http.StatusUnprocessableEntity || !strings.Contains(reused.Body.String(), string(apperr.IdempotencyKeyReused)) {
+		t.Errorf("Expected 422 IDEMPOTENCY_KEY_REUSED, got %d %s", reused.Code, reused.Body.String())
+	}
+}
+
+func TestIdempotencyRetriesServerErrors(t *testing.T) {
+	pool, _ := memorydb.NewPool()
+	var calls atomic.Int32
+	handler := httpserver.Idempotency(httpserver.IdempotencyOptions{Pool: pool})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			httpserver.Error(apperr.NewInternalError("database down"), w, r)
+			return
+		}
+		httpserver.Success("ok", w, r)
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("key-3", ""))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, idempotentRequest("key-3", ""))
+
+	if first.Code != http.StatusInternalServerError || second.Code != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("Expected the failed request to run again, got %d then %d after %d calls", first.Code, second.Code, calls.Load())
+	}
+}
+
+// ctxPool gives stores that fail once their context is done, like redisdb ones.
+type ctxPool struct {
+	*memorydb.Pool
+}
+
+type ctxStore struct {
+	cache.AtomicStore
+	ctx context.Context
+}
+
+func (p ctxPool) NewStore(ctx context.Context) cache.Store {
+	return ctxStore{p.Pool.NewStore(ctx).(cache.AtomicStore), ctx}
+}
+
+func (s ctxStore) CacheJSON(key string, v interface{}, duration time.Duration) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return s.AtomicStore.CacheJSON(key, v, duration)
+}
+
+func TestIdempotencyStoresResponseAfterClientLeft(t *testing.T) {
+	pool, _ := memorydb.NewPool()
+	var calls atomic.Int32
+	handler := httpserver.Idempotency(httpserver.IdempotencyOptions{Pool: ctxPool{pool}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		httpserver.Success("ok", w, r, http.StatusCreated)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-4", "").WithContext(ctx))
+
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("key-4", ""))
+	if calls.Load() != 1 || retry.Code != http.StatusCreated {
+		t.Errorf("Expected the retry to be replayed, got %d after %d calls", retry.Code, calls.Load())
+	}
+}
+
+func TestIdempotencyKeysDoNotCollideAcrossActors(t *testing.T) {
+	pool, _ := memorydb.NewPool()
+	var calls atomic.Int32
+	handler := httpserver.Idempotency(httpserver.IdempotencyOptions{Pool: pool})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		httpserver.Success("ok", w, r)
+	}))
+
+	for _, tc := range []struct{ actor, key string }{{"1", "23x"}, {"12", "3x"}} {
+		req := idempotentRequest(tc.key, "")
+		req = req.WithContext(context.WithValue(req.Context(), httpserver.ActorLogKey, tc.actor))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected the handler to run for each actor, ran %d times", calls.Load())
+	}
+}
+
+func TestIdempotencyStreamsLargeBodies(t *testing.T) {
+	pool, _ := memorydb.NewPool()
+	var calls atomic.Int32
+	handler := httpserver.Idempotency(httpserver.IdempotencyOptions{Pool: pool})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		n, _ := io.Copy(io.Discard, r.Body)
+		httpserver.Success(n, w, r, http.StatusCreated)
+	}))
+
+	body := strings.Repeat("a", httpserver.DefaultMaxBodySize+1)
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("key-5", body))
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 for a body over DefaultMaxBodySize, got %d", first.Code)
+	}
+
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("key-5", body))
+	if calls.Load() != 1 || retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected the retry to be replayed, got %d after %d calls", retry.Code, calls.Load())
+	}
+
+	other := httptest.NewRecorder()
+	handler.ServeHTTP(other, idempotentRequest("key-5", strings.Repeat("b", len(body))))
+	if other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for another body, got %d", other.Code)
+	}
+}
+
+func TestIdempotencyMaxBodySize(t *testing.T) {
+	pool, _ := memorydb.NewPool()
+	handler := httpserver.Idempotency(httpserver.IdempotencyOptions{Pool: pool, MaxBodySize: 10})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]string
+		if err := httpserver.Bind(r).JSONBody(&v).Err(); err != nil {
+			httpserver.Error(err, w, r)
+			return
+		}
+		httpserver.Success(v, w, r)
+	}))
+
+	req := idempotentRequest("key-6", `{"item":"a long book title"}`)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", rec.Code)
+	}
+}
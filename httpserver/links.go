@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+)
+
+// pageInfoer is implemented by prim.PaginatedData.
+type pageInfoer interface {
+	PageInfo() prim.PageInfo
+}
+
+// setPageLinks sets the RFC 8288 Link header of a paginated response, with
+// first, prev, next and last links to the request URL with its page and limit
+// query params replaced. last is only set when the total count is known.
+func setPageLinks(w http.ResponseWriter, r *http.Request, data any) {
+	pd, ok := data.(pageInfoer)
+	if !ok {
+		return
+	}
+	info := pd.PageInfo()
+
+	link := func(page int, rel string) string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("limit", strconv.Itoa(info.Limit))
+		u.RawQuery = q.Encode()
+		u.Scheme, u.Host = "", ""
+		return "<" + u.RequestURI() + `>; rel="` + rel + `"`
+	}
+
+	links := []string{link(0, "first")}
+	if info.Page > 0 {
+		links = append(links, link(info.Page-1, "prev"))
+	}
+	if info.HasNext {
+		links = append(links, link(info.Page+1, "next"))
+	}
+	if info.TotalPages != nil && *info.TotalPages > 0 {
+		links = append(links, link(*info.TotalPages-1, "last"))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,36 @@
+package httpserver_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+	"github.com/kgjoner/cornucopia/v3/prim"
+)
+
+func TestSuccessPageLinks(t *testing.T) {
+	data := prim.NewPaginatedData(prim.Pagination{Page: 1, Limit: 10, HasNext: true}, []int{1})
+	data.SetTotal(35)
+
+	rec := httptest.NewRecorder()
+	httpserver.Success(data, rec, httptest.NewRequest("GET", "http://api.test/items?status=active&page=1", nil))
+
+	want := `</items?limit=10&page=0&status=active>; rel="first", ` +
+		`</items?limit=10&page=0&status=active>; rel="prev", ` +
+		`</items?limit=10&page=2&status=active>; rel="next", ` +
+		`</items?limit=10&page=3&status=active>; rel="last"`
+	if got := rec.Header().Get("Link"); got != want {
+		t.Errorf("Expected Link %q, got %q", want, got)
+	}
+}
+
+func TestSuccessPageLinksWithoutTotal(t *testing.T) {
+	data := prim.NewPaginatedData(prim.Pagination{Limit: 20}, []int{1})
+
+	rec := httptest.NewRecorder()
+	httpserver.Success(*data, rec, httptest.NewRequest("GET", "/items", nil))
+
+	if got, want := rec.Header().Get("Link"), `</items?limit=20&page=0>; rel="first"`; got != want {
+		t.Errorf("Expected Link %q, got %q", want, got)
+	}
+}
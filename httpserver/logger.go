@@ -3,8 +3,10 @@ package httpserver
 import (
 	"errors"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/logger"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -12,41 +14,94 @@ type ctxKey string
 
 const ActorLogKey = ctxKey("actor.log")
 
+var currentLogger atomic.Pointer[logger.Logger]
+
+// SetLogger sets the logger used by Error, Success and AccessLog. Passing nil
+// restores the default, which logs through slog.Default().
+//
+//	httpserver.SetLogger(logger.FromLogrus(nil)) // keep logging through logrus
+func SetLogger(l logger.Logger) {
+	if l == nil {
+		currentLogger.Store(nil)
+		return
+	}
+	currentLogger.Store(&l)
+}
+
+func getLogger() logger.Logger {
+	if l := currentLogger.Load(); l != nil {
+		return *l
+	}
+	return logger.FromSlog(nil)
+}
+
+// logRequest logs msg with the request fields of data (see NewLogger) and extra.
+func logRequest(r *http.Request, level logger.Level, msg string, data any, extra logger.Fields) {
+	fields := logFields(r, data)
+	for k, v := range extra {
+		fields[k] = v
+	}
+	getLogger().Log(r.Context(), level, msg, fields)
+}
+
+// NewLogger returns a logrus entry with the request fields: method, path, actor
+// and request ID, plus kind, code, metadata and stack when data is an error.
+//
+// Deprecated: httpserver logs through the logger.Logger set with SetLogger.
+// NewLogger is kept for callers that log through the global logrus logger.
 func NewLogger(r *http.Request, data interface{}) *log.Entry {
-	actor := r.Context().Value(ActorLogKey)
+	return log.WithFields(log.Fields(logFields(r, data)))
+}
+
+func logFields(r *http.Request, data any) logger.Fields {
+	fields := logger.Fields{
+		"Method":    r.Method,
+		"Path":      r.URL.Path,
+		"Actor":     r.Context().Value(ActorLogKey),
+		"RequestID": ParseRequestID(r),
+	}
 
 	if err, ok := data.(error); ok {
 		var appErr *apperr.AppError
 		if errors.As(err, &appErr) {
-			return log.WithFields(log.Fields{
-				"Method": r.Method,
-				"Path":   r.URL.Path,
-				"Actor":  actor,
-				"Kind":   appErr.Kind,
-				"Code":   appErr.Code,
-			})
+			for k, v := range apperr.MetaOf(err) {
+				if _, exists := fields[k]; !exists {
+					fields[k] = v
+				}
+			}
+			fields["Kind"] = appErr.Kind
+			fields["Code"] = appErr.Code
+			if appErr.Kind == apperr.Internal || appErr.Kind == apperr.External {
+				if stack := appErr.StackTrace(); stack != nil {
+					fields["Stack"] = stack.String()
+				}
+			}
+			return fields
 		}
-		return log.WithFields(log.Fields{
-			"Method": r.Method,
-			"Path":   r.URL.Path,
-			"Actor":  actor,
-			"Kind":   "Unknown",
-			"Code":   "Unexpected",
-		})
+		fields["Kind"] = "Unknown"
+		fields["Code"] = "Unexpected"
+		return fields
 	}
 
 	if data == 201 {
-		return log.WithFields(log.Fields{
-			"Method": r.Method,
-			"Path":   r.URL.Path,
-			"Actor":  actor,
-			"Kind":   "Creation",
-		})
+		fields["Kind"] = "Creation"
 	}
 
-	return log.WithFields(log.Fields{
-		"Method": r.Method,
-		"Path":   r.URL.Path,
-		"Actor":  actor,
-	})
+	return fields
+}
+
+// logLevelOf converts a registry log level, defaulting to error.
+func logLevelOf(level apperr.LogLevel) logger.Level {
+	switch level {
+	case apperr.LogDebug:
+		return logger.LevelDebug
+	case apperr.LogInfo:
+		return logger.LevelInfo
+	case apperr.LogWarn:
+		return logger.LevelWarn
+	case apperr.LogFatal:
+		return logger.LevelFatal
+	default:
+		return logger.LevelError
+	}
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/kgjoner/cornucopia/v3/apperr"
 	"github.com/kgjoner/cornucopia/v3/httpserver"
+	"github.com/kgjoner/cornucopia/v3/logger"
 )
 
 func TestNewLogger_withAppError(t *testing.T) {
@@ -38,3 +39,32 @@ func TestNewLogger_withStatus(t *testing.T) {
 		t.Error("Expected a log entry, got nil")
 	}
 }
+
+func TestNewLogger_withStack(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	entry := httpserver.NewLogger(req, apperr.New(apperr.Internal, apperr.Unexpected, "boom", apperr.WithStack()))
+	if _, ok := entry.Data["Stack"]; !ok {
+		t.Error("Expected Stack field for internal error")
+	}
+
+	entry = httpserver.NewLogger(req, apperr.New(apperr.Validation, apperr.InvalidData, "bad", apperr.WithStack()))
+	if _, ok := entry.Data["Stack"]; ok {
+		t.Error("Expected no Stack field for validation error")
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	logs := captureLogs(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	httpserver.Error(apperr.NewConflictError("duplicate"), httptest.NewRecorder(), req)
+
+	if len(*logs) != 1 {
+		t.Fatalf("Expected one entry, got %d", len(*logs))
+	}
+	entry := (*logs)[0]
+	if entry.level != logger.LevelError || entry.msg != "duplicate" || entry.fields["Code"] != apperr.Inconsistency {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+}
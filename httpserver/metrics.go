@@ -0,0 +1,78 @@
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics records request counts and durations on a caller-provided registry,
+// so several servers (or tests) in one process can keep separate metrics.
+//
+// Usage:
+//
+//	m, err := httpserver.NewMetrics(prometheus.DefaultRegisterer)
+//	router.Use(m.Middleware)
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewMetrics creates the http_requests_total counter, labelled by method,
+// route, status, kind and code, and the http_request_duration_seconds
+// histogram, labelled by method, route and status, registering both on reg.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "The total number of handled requests",
+		}, []string{"method", "route", "status", "kind", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "The request handling duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	if err := reg.Register(m.requests); err != nil {
+		return nil, err
+	}
+	if err := reg.Register(m.duration); err != nil {
+		reg.Unregister(m.requests)
+		return nil, err
+	}
+	return m, nil
+}
+
+// Middleware records a request once next has handled it. The error kind and
+// code come from the error passed to Error, if any.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := wrapResponseWriter(w)
+		r, info := withResponseInfo(r)
+
+		next.ServeHTTP(rw, r)
+
+		route := routePattern(r)
+		status := strconv.Itoa(rw.Status())
+		kind, code := info.kindAndCode()
+
+		m.requests.WithLabelValues(r.Method, route, status, string(kind), string(code)).Inc()
+		m.duration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routePattern returns the chi route pattern matched by r, or "unmatched" so
+// raw paths never become label values.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
@@ -0,0 +1,84 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := httpserver.NewMetrics(reg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	router := chi.NewRouter()
+	router.Use(m.Middleware)
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "0" {
+			httpserver.Error(apperr.NewValidationError("invalid id"), w, r)
+			return
+		}
+		httpserver.Success("ok", w, r)
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/items/0", "/nowhere"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := map[[5]string]float64{
+		{"GET", "/items/{id}", "200", "", ""}:                                                2,
+		{"GET", "/items/{id}", "422", string(apperr.Validation), string(apperr.InvalidData)}: 1,
+		{"GET", "unmatched", "404", "", ""}:                                                  1,
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected gather error: %v", err)
+	}
+	got := map[[5]string]float64{}
+	for _, family := range families {
+		if family.GetName() != "http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, pair := range metric.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			key := [5]string{labels["method"], labels["route"], labels["status"], labels["kind"], labels["code"]}
+			got[key] = metric.GetCounter().GetValue()
+		}
+	}
+	for key, value := range expected {
+		if got[key] != value {
+			t.Errorf("Expected %v for %v, got %v", value, key, got[key])
+		}
+	}
+
+	if n := testutil.CollectAndCount(reg, "http_request_duration_seconds"); n != 3 {
+		t.Errorf("Expected 3 duration series, got %d", n)
+	}
+}
+
+func TestNewMetricsIsolatedRegistries(t *testing.T) {
+	if _, err := httpserver.NewMetrics(prometheus.NewRegistry()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := httpserver.NewMetrics(prometheus.NewRegistry()); err != nil {
+		t.Fatalf("Expected a second registry to accept metrics, got %v", err)
+	}
+
+	reg := prometheus.NewRegistry()
+	httpserver.NewMetrics(reg)
+	if _, err := httpserver.NewMetrics(reg); err == nil {
+		t.Error("Expected duplicate registration on one registry to fail")
+	}
+}
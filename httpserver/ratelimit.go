@@ -0,0 +1,98 @@
+package httpserver
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/cache"
+	"github.com/kgjoner/cornucopia/v3/logger"
+)
+
+type RateLimitOptions struct {
+	// Limiter counts the hits, e.g. a memorydb or redisdb RateLimiter.
+	Limiter cache.RateLimiter
+	// Rate is the limit of each key, by token bucket or sliding window.
+	Rate cache.Rate
+	// Key tells whom a request counts for. Defaults to RateLimitByActor.
+	Key func(r *http.Request) string
+	// KeyGen builds the limiter keys. Defaults to the "ratelimit" prefix.
+	// Middlewares with the same KeyGen and Rate share their counts.
+	KeyGen *cache.KeyGen
+}
+
+// RateLimit limits the requests of each key, as given by opts.Key, to
+// opts.Rate. Responses carry the RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers, and requests over the limit
+// fail with RATE_LIMITED (429) and a Retry-After header. Should the limiter
+// fail, requests are let through and the failure is logged. RateLimit panics
+// if the rate's limit or window is not positive.
+func RateLimit(opts RateLimitOptions) func(http.Handler) http.Handler {
+	if opts.Rate.Limit <= 0 || opts.Rate.Window <= 0 {
+		panic("httpserver: rate limit and window must be positive")
+	}
+	if opts.Key == nil {
+		opts.Key = RateLimitByActor
+	}
+	if opts.KeyGen == nil {
+		opts.KeyGen = cache.NewKeyGen("ratelimit")
+	}
+	policy := fmt.Sprintf("%d;w=%d", opts.Rate.Limit, ceilSeconds(opts.Rate.Window))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.KeyGen.Key(joinKeyParts(
+				strconv.Itoa(opts.Rate.Limit), opts.Rate.Window.String(), strconv.Itoa(int(opts.Rate.Algorithm)), opts.Key(r),
+			))
+			res, err := opts.Limiter.Allow(r.Context(), key, opts.Rate)
+			if err != nil {
+				logRequest(r, logger.LevelError, "rate limiter failed", err, nil)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(opts.Rate.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(max(res.Remaining, 0)))
+			h.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(res.Reset), 10))
+			h.Set("RateLimit-Policy", policy)
+
+			if !res.Allowed {
+				retryAfter := max(ceilSeconds(res.RetryAfter), 1)
+				h.Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+				Error(apperr.RetryableAfter(apperr.NewTooManyRequestsError(
+					fmt.Sprintf("too many requests, retry in %d seconds", retryAfter),
+				), res.RetryAfter), w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitByIP counts requests for the client IP (see ParseIP).
+func RateLimitByIP(r *http.Request) string {
+	ip := ParseIP(r)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return "ip:" + ip
+}
+
+// RateLimitByActor counts requests for the actor (see ActorLogKey), or for
+// the client IP when there is none.
+func RateLimitByActor(r *http.Request) string {
+	if actor := r.Context().Value(ActorLogKey); actor != nil {
+		return fmt.Sprintf("actor:%v", actor)
+	}
+	return RateLimitByIP(r)
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
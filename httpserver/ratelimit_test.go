@@ -0,0 +1,144 @@
+package httpserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/cache"
+	"github.com/kgjoner/cornucopia/v3/cache/memorydb"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+)
+
+func rateLimitedHandler(opts httpserver.RateLimitOptions) http.Handler {
+	return httpserver.RateLimit(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpserver.Success("ok", w, r)
+	}))
+}
+
+func TestRateLimitRejectsOverLimit(t *testing.T) {
+	for _, algorithm := range []cache.RateAlgorithm{cache.TokenBucket, cache.SlidingWindow} {
+		handler := rateLimitedHandler(httpserver.RateLimitOptions{
+			Limiter: memorydb.NewRateLimiter(),
+			Rate:    cache.Rate{Limit: 2, Window: time.Minute, Algorithm: algorithm},
+			Key:     httpserver.RateLimitByIP,
+		})
+
+		for i, remaining := range []string{"1", "0"} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("POST", "/login", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected request %d to pass, got %d", i+1, rec.Code)
+			}
+			if rec.Header().Get("RateLimit-Limit") != "2" || rec.Header().Get("RateLimit-Remaining") != remaining {
+				t.Errorf("Expected 2 limit and %s remaining, got %v", remaining, rec.Header())
+			}
+			if rec.Header().Get("RateLimit-Policy") != "2;w=60" {
+				t.Errorf("Expected 2;w=60 policy, got %q", rec.Header().Get("RateLimit-Policy"))
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/login", nil))
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected 429, got %d", rec.Code)
+		}
+		retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		if err != nil || retryAfter < 1 || retryAfter > 60 {
+			t.Errorf("Expected Retry-After within the window, got %q", rec.Header().Get("Retry-After"))
+		}
+		if rec.Header().Get("RateLimit-Remaining") != "0" {
+			t.Errorf("Expected 0 remaining, got %q", rec.Header().Get("RateLimit-Remaining"))
+		}
+
+		var body map[string]any
+		json.NewDecoder(rec.Body).Decode(&body)
+		if body["kind"] != string(apperr.TooManyRequests) || body["code"] != string(apperr.RateLimited) {
+			t.Errorf("Expected TooManyRequests RATE_LIMITED, got %v", body)
+		}
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	handler := rateLimitedHandler(httpserver.RateLimitOptions{
+		Limiter: memorydb.NewRateLimiter(),
+		Rate:    cache.Rate{Limit: 1, Window: time.Minute},
+	})
+
+	request := func(ip, actor string) int {
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = ip
+		if actor != "" {
+			req = req.WithContext(context.WithValue(req.Context(), httpserver.ActorLogKey, actor))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request("10.0.0.1:1234", ""); code != http.StatusOK {
+		t.Fatalf("Expected first anonymous request to pass, got %d", code)
+	}
+	// Another port of the same IP counts for the same client.
+	if code := request("10.0.0.1:5678", ""); code != http.StatusTooManyRequests {
+		t.Errorf("Expected anonymous request from the same IP to be limited, got %d", code)
+	}
+	if code := request("10.0.0.2:1234", ""); code != http.StatusOK {
+		t.Errorf("Expected request from another IP to pass, got %d", code)
+	}
+
+	// Actors are counted apart from their IP.
+	if code := request("10.0.0.1:1234", "user-1"); code != http.StatusOK {
+		t.Errorf("Expected first request of an actor to pass, got %d", code)
+	}
+	if code := request("10.0.0.3:1234", "user-1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected actor to be limited from any IP, got %d", code)
+	}
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, rate cache.Rate) (cache.RateResult, error) {
+	return cache.RateResult{}, errors.New("redis down")
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	handler := rateLimitedHandler(httpserver.RateLimitOptions{
+		Limiter: failingLimiter{},
+		Rate:    cache.Rate{Limit: 1, Window: time.Minute},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/login", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected request to pass when the limiter fails, got %d", rec.Code)
+	}
+	if rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("Expected no RateLimit headers, got %v", rec.Header())
+	}
+}
+
+func TestRateLimitRatesDoNotShareCounts(t *testing.T) {
+	limiter := memorydb.NewRateLimiter()
+	first := rateLimitedHandler(httpserver.RateLimitOptions{
+		Limiter: limiter,
+		Rate:    cache.Rate{Limit: 1, Window: 15 * time.Second},
+	})
+	second := rateLimitedHandler(httpserver.RateLimitOptions{
+		Limiter: limiter,
+		Rate:    cache.Rate{Limit: 11, Window: 5 * time.Second},
+	})
+
+	first.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/login", nil))
+	rec := httptest.NewRecorder()
+	second.ServeHTTP(rec, httptest.NewRequest("POST", "/login", nil))
+	if rec.Header().Get("RateLimit-Remaining") != "10" {
+		t.Errorf("Expected another rate to have its own count, got %q remaining", rec.Header().Get("RateLimit-Remaining"))
+	}
+}
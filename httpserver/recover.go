@@ -0,0 +1,34 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+// Recoverer is a middleware that recovers from panics in next and answers them
+// through Error as a fatal Internal AppError carrying the panic stack.
+// http.ErrAbortHandler panics are re-raised so the server aborts the response.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			cause, ok := rec.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", rec)
+			}
+			err := apperr.Wrap(cause, apperr.Internal, apperr.Unexpected, "unexpected error", apperr.WithStack())
+			Error(apperr.Fatal(err), w, r)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,51 @@
+package httpserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecoverer(t *testing.T) {
+	handler := httpserver.Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map write")
+	}))
+
+	before := testutil.ToFloat64(httpserver.ErrCounters[http.StatusInternalServerError])
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d", rec.Code)
+	}
+	if got := testutil.ToFloat64(httpserver.ErrCounters[http.StatusInternalServerError]); got != before+1 {
+		t.Errorf("Expected 500 counter to be incremented, got %v -> %v", before, got)
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Expected JSON body, got error: %v", err)
+	}
+	if body["kind"] != string(apperr.Internal) || body["message"] != "unexpected error" {
+		t.Errorf("Unexpected body: %v", body)
+	}
+}
+
+func TestRecovererReraisesAbort(t *testing.T) {
+	handler := httpserver.Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("Expected ErrAbortHandler to be re-raised")
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
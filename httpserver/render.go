@@ -0,0 +1,126 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+// ErrorRenderer writes the response of Error. err is the public, already
+// localized error: either an *apperr.AppError or an *apperr.MultiError.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, status int, err error)
+
+var errorRenderer atomic.Pointer[ErrorRenderer]
+
+// SetErrorRenderer sets the renderer used by Error. Passing nil restores the
+// default JSONErrorRenderer.
+func SetErrorRenderer(renderer ErrorRenderer) {
+	if renderer == nil {
+		errorRenderer.Store(nil)
+		return
+	}
+	errorRenderer.Store(&renderer)
+}
+
+func renderError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if renderer := errorRenderer.Load(); renderer != nil {
+		(*renderer)(w, r, status, err)
+		return
+	}
+	JSONErrorRenderer(w, r, status, err)
+}
+
+// JSONErrorRenderer writes err in the default {kind, code, message} format,
+// plus requestId when the request carries one.
+func JSONErrorRenderer(w http.ResponseWriter, r *http.Request, status int, err error) {
+	var body any = err
+	if id := ParseRequestID(r); id != "" {
+		body = withRequestID(err, id)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// withRequestID returns the JSON object of v with an added requestId member.
+func withRequestID(v any, id string) any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil {
+		return v
+	}
+	obj["requestId"], _ = json.Marshal(id)
+	return obj
+}
+
+// ProblemDetails is an RFC 9457 problem details object. RequestID, Kind and Code are
+// extension members carrying the apperr classification.
+type ProblemDetails struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+	Kind      apperr.Kind `json:"kind,omitempty"`
+	Code      apperr.Code `json:"code,omitempty"`
+	// Errors holds field details of an apperr.MapError, keyed by field.
+	Errors *apperr.MapError `json:"errors,omitempty"`
+	// Items holds the member errors of an apperr.MultiError.
+	Items []apperr.ItemError `json:"items,omitempty"`
+}
+
+// ProblemDetailsRenderer returns an ErrorRenderer that writes
+// application/problem+json bodies. The problem type is typeBaseURI followed by
+// the lowercased, dash-separated error code (e.g. "https://errors.example.com/invalid-data");
+// an empty typeBaseURI yields "about:blank".
+func ProblemDetailsRenderer(typeBaseURI string) ErrorRenderer {
+	return func(w http.ResponseWriter, r *http.Request, status int, err error) {
+		problem := NewProblemDetails(typeBaseURI, status, err)
+		problem.Instance = r.URL.Path
+		problem.RequestID = ParseRequestID(r)
+
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(problem)
+	}
+}
+
+// NewProblemDetails builds the problem details of a public error, as given to
+// an ErrorRenderer.
+func NewProblemDetails(typeBaseURI string, status int, err error) ProblemDetails {
+	problem := ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+
+	var e *apperr.AppError
+	switch v := err.(type) {
+	case *apperr.MultiError:
+		e = v.AppError()
+		problem.Items = v.Items
+	case *apperr.AppError:
+		e = v
+		problem.Errors = v.PublicDetails()
+	default:
+		problem.Detail = err.Error()
+		return problem
+	}
+
+	problem.Detail = e.PublicMessage()
+	problem.Kind = e.Kind
+	problem.Code = e.Code
+	if typeBaseURI != "" {
+		slug := strings.ReplaceAll(strings.ToLower(string(e.Code)), "_", "-")
+		problem.Type = strings.TrimSuffix(typeBaseURI, "/") + "/" + slug
+	}
+	return problem
+}
@@ -0,0 +1,29 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/kgjoner/cornucopia/v3/requestid"
+)
+
+// RequestID is a middleware that reads the X-Request-ID header, or generates an
+// ID when it is absent or invalid, stores it in the request context and echoes
+// it in the response header. NewLogger, Error and httpclient pick it up from
+// the context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.IsValid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// ParseRequestID returns the request ID stored by the RequestID middleware.
+func ParseRequestID(r *http.Request) string {
+	id, _ := requestid.FromContext(r.Context())
+	return id
+}
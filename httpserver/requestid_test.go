@@ -0,0 +1,59 @@
+package httpserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := httpserver.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = httpserver.ParseRequestID(r)
+		if got := httpserver.NewLogger(r, nil).Data["RequestID"]; got != seen {
+			t.Errorf("Expected logger RequestID %q, got %v", seen, got)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if seen != "abc-123" {
+		t.Errorf("Expected incoming ID to be kept, got %q", seen)
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "abc-123" {
+		t.Errorf("Expected ID in response header, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "not valid")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == "" || seen == "not valid" {
+		t.Errorf("Expected a generated ID for invalid input, got %q", seen)
+	}
+}
+
+func TestRequestIDInErrorBody(t *testing.T) {
+	handler := httpserver.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpserver.Error(apperr.NewRequestError("bad"), w, r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	if body["requestId"] != "req-42" || body["message"] != "bad" {
+		t.Errorf("Unexpected body: %v", body)
+	}
+}
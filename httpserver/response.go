@@ -0,0 +1,132 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/logger"
+)
+
+// responseWriter records the status and size of a response for middlewares.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Status returns the written status, or 200 if nothing was written yet.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets handlers take over the connection, e.g. to upgrade it to a
+// WebSocket, when the underlying writer supports it.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// responseInfo is shared through the request context so that Success and
+// Error can tell middlewares about the response they wrote.
+type responseInfo struct {
+	// req is the request as seen by the handler, with any context values set
+	// by inner middlewares (e.g. the actor).
+	req      *http.Request
+	err      error
+	logLevel logger.Level
+	// accessLogged tells Success and Error that AccessLog logs the request, so
+	// they must not log it themselves.
+	accessLogged bool
+}
+
+const responseInfoKey = ctxKey("response.info")
+
+// withResponseInfo returns r with a responseInfo in its context, reusing one
+// set by an outer middleware.
+func withResponseInfo(r *http.Request) (*http.Request, *responseInfo) {
+	if info := responseInfoOf(r); info != nil {
+		return r, info
+	}
+	info := &responseInfo{}
+	return r.WithContext(context.WithValue(r.Context(), responseInfoKey, info)), info
+}
+
+// responseInfoOf returns the responseInfo set by a middleware, or nil.
+func responseInfoOf(r *http.Request) *responseInfo {
+	info, _ := r.Context().Value(responseInfoKey).(*responseInfo)
+	return info
+}
+
+// recordResponse stores the outcome of a response and reports whether the
+// caller should log it itself.
+func recordResponse(r *http.Request, err error, logLevel logger.Level) (shouldLog bool) {
+	info := responseInfoOf(r)
+	if info == nil {
+		return true
+	}
+	info.req = r
+	info.err = err
+	info.logLevel = logLevel
+	return !info.accessLogged
+}
+
+// kindAndCode returns the kind and code of the recorded error, if any.
+func (i *responseInfo) kindAndCode() (apperr.Kind, apperr.Code) {
+	var multi *apperr.MultiError
+	if errors.As(i.err, &multi) {
+		if outer := outerAppError(i.err, multi); outer != nil {
+			return outer.Kind, outer.Code
+		}
+		return multi.Kind(), multi.Code()
+	}
+	var e *apperr.AppError
+	if errors.As(i.err, &e) {
+		return e.Kind, e.Code
+	}
+	return "", ""
+}
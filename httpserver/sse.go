@@ -0,0 +1,178 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/logger"
+)
+
+// Event is a Server-Sent Event. Data is written as is when it is a string and
+// as JSON otherwise.
+type Event struct {
+	ID    string
+	Event string
+	Data  any
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+type SSEOptions struct {
+	// Heartbeat is the interval of comment lines sent to keep idle connections
+	// open through proxies. Zero disables it.
+	Heartbeat time.Duration
+	// Retry is the reconnection delay sent to the client when the stream opens.
+	Retry time.Duration
+}
+
+// ErrSSEClosed is returned when sending on a closed SSEWriter.
+var ErrSSEClosed = errors.New("httpserver: event stream closed")
+
+// SSEWriter writes Server-Sent Events to a response. It is safe for concurrent
+// use. Close must be called before the handler returns.
+type SSEWriter struct {
+	mu  sync.Mutex
+	w   http.ResponseWriter
+	r   *http.Request
+	rc  *http.ResponseController
+	err error
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSSEWriter starts an event stream response and, if set in opts, its
+// heartbeat.
+func NewSSEWriter(w http.ResponseWriter, r *http.Request, opts SSEOptions) *SSEWriter {
+	recordResponse(r, nil, logger.LevelInfo)
+	Counter200.Inc()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	s := &SSEWriter{
+		w:    w,
+		r:    r,
+		rc:   http.NewResponseController(w),
+		stop: make(chan struct{}),
+	}
+
+	if opts.Retry > 0 {
+		s.write("retry: " + strconv.FormatInt(opts.Retry.Milliseconds(), 10) + "\n\n")
+	} else {
+		s.write(": stream opened\n\n")
+	}
+
+	if opts.Heartbeat > 0 {
+		go s.heartbeat(opts.Heartbeat)
+	}
+	return s
+}
+
+// Send writes ev. It returns the context error once the client has gone
+// away, and the first write error after a failed write.
+func (s *SSEWriter) Send(ev Event) error {
+	var sb strings.Builder
+	if ev.ID != "" {
+		sb.WriteString("id: " + sseField(ev.ID) + "\n")
+	}
+	if ev.Event != "" {
+		sb.WriteString("event: " + sseField(ev.Event) + "\n")
+	}
+	if ev.Retry > 0 {
+		sb.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	data, ok := ev.Data.(string)
+	if !ok {
+		raw, err := json.Marshal(ev.Data)
+		if err != nil {
+			return err
+		}
+		data = string(raw)
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+
+	return s.write(sb.String())
+}
+
+// SendError logs err like Error does and sends its public form, as written by
+// JSONErrorRenderer, in an "error" event.
+func (s *SSEWriter) SendError(err error) error {
+	return s.Send(Event{Event: "error", Data: inBandError(err, s.r)})
+}
+
+// Done is closed when the client goes away.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.r.Context().Done()
+}
+
+// Close stops the heartbeat. Later sends return ErrSSEClosed.
+func (s *SSEWriter) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+
+	// Wait for a heartbeat write in progress.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = ErrSSEClosed
+	}
+}
+
+func (s *SSEWriter) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.Done():
+			return
+		case <-ticker.C:
+			if s.write(": heartbeat\n\n") != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *SSEWriter) write(chunk string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.r.Context().Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.w.Write([]byte(chunk)); err != nil {
+		s.err = err
+		return err
+	}
+	if err := flush(s.rc); err != nil {
+		s.err = err
+		return err
+	}
+	return nil
+}
+
+// sseField drops line breaks, which would end the field early.
+func sseField(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
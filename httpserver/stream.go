@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+
+	"github.com/kgjoner/cornucopia/v3/logger"
+)
+
+// StreamNDJSON writes each item as a line of JSON, flushing after every line,
+// so that large listings are sent as they are produced. It stops when the
+// client goes away, returning the context error.
+//
+// An error yielded by items ends the stream: it is logged like in Error and
+// written in-band as a last {"error": {kind, code, message, ...}} line. Since
+// the status has already been sent, the returned error must not be passed to
+// Error afterwards.
+func StreamNDJSON[T any](items iter.Seq2[T, error], w http.ResponseWriter, r *http.Request) error {
+	recordResponse(r, nil, logger.LevelInfo)
+	Counter200.Inc()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	ctx := r.Context()
+	for item, err := range items {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var line []byte
+		if err == nil {
+			line, err = json.Marshal(item)
+		}
+		if err != nil {
+			line, _ = json.Marshal(ndjsonError{inBandError(err, r)})
+		}
+
+		if _, writeErr := w.Write(append(line, '\n')); writeErr != nil {
+			return writeErr
+		}
+		if flushErr := flush(rc); flushErr != nil {
+			return flushErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ndjsonError struct {
+	Error any `json:"error"`
+}
+
+// SeqItems adapts an iterator that cannot fail to StreamNDJSON.
+func SeqItems[T any](seq iter.Seq[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for item := range seq {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ChanItems yields the values received from ch until it is closed. If ctx is
+// done first, it yields ctx.Err() and stops.
+func ChanItems[T any](ctx context.Context, ch <-chan T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				var zero T
+				yield(zero, ctx.Err())
+				return
+			case item, ok := <-ch:
+				if !ok || !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// inBandError records and logs err, and returns its public form, as written by
+// JSONErrorRenderer, for use inside an already started response.
+func inBandError(err error, r *http.Request) any {
+	_, public := resolveError(err, r)
+	if id := ParseRequestID(r); id != "" {
+		return withRequestID(public, id)
+	}
+	return public
+}
+
+// flush sends buffered data to the client, if the writer supports it.
+func flush(rc *http.ResponseController) error {
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+)
+
+type streamItem struct {
+	ID int `json:"id"`
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	items := httpserver.SeqItems(slices.Values([]streamItem{{1}, {2}}))
+
+	err := httpserver.StreamNDJSON(items, rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got %q", ct)
+	}
+	if !rec.Flushed {
+		t.Error("Expected response to be flushed")
+	}
+	if body := rec.Body.String(); body != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Errorf("Unexpected body: %q", body)
+	}
+}
+
+func TestStreamNDJSONInBandError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	failure := apperr.NewConflictError("stock changed", apperr.Inconsistency)
+	items := func(yield func(streamItem, error) bool) {
+		if yield(streamItem{1}, nil) {
+			yield(streamItem{}, failure)
+		}
+	}
+
+	err := httpserver.StreamNDJSON(iter.Seq2[streamItem, error](items), rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the yielded error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", rec.Body.String())
+	}
+	want := `{"error":{"kind":"Conflict","code":"INCONSISTENCY","message":"stock changed"}}`
+	if lines[1] != want {
+		t.Errorf("Expected %s, got %s", want, lines[1])
+	}
+}
+
+func TestStreamNDJSONStopsOnDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan streamItem, 1)
+	ch <- streamItem{1}
+
+	// The client goes away once the first item has been written.
+	items := func(yield func(streamItem, error) bool) {
+		for item, err := range httpserver.ChanItems(ctx, ch) {
+			if !yield(item, err) {
+				return
+			}
+			cancel()
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	err := httpserver.StreamNDJSON(iter.Seq2[streamItem, error](items), rec, req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if body := rec.Body.String(); body != "{\"id\":1}\n" {
+		t.Errorf("Unexpected body: %q", body)
+	}
+}
+
+func TestSSEWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	s := httpserver.NewSSEWriter(rec, req, httpserver.SSEOptions{Retry: 3 * time.Second})
+	s.Send(httpserver.Event{ID: "7", Event: "update", Data: streamItem{7}})
+	s.Send(httpserver.Event{Data: "line one\nline two"})
+	s.SendError(apperr.NewRequestError("bad cursor", apperr.BadRequest))
+	s.Close()
+
+	if err := s.Send(httpserver.Event{Data: "late"}); !errors.Is(err, httpserver.ErrSSEClosed) {
+		t.Errorf("Expected ErrSSEClosed, got %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+	want := "retry: 3000\n\n" +
+		"id: 7\nevent: update\ndata: {\"id\":7}\n\n" +
+		"data: line one\ndata: line two\n\n" +
+		"event: error\ndata: {\"kind\":\"Request\",\"code\":\"BAD_REQUEST\",\"message\":\"bad cursor\"}\n\n"
+	if body := rec.Body.String(); body != want {
+		t.Errorf("Expected %q, got %q", want, body)
+	}
+}
+
+func TestSSEWriterHeartbeatAndDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	s := httpserver.NewSSEWriter(rec, req, httpserver.SSEOptions{Heartbeat: time.Millisecond})
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-s.Done()
+
+	if err := s.Send(httpserver.Event{Data: "gone"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	s.Close()
+
+	if body := rec.Body.String(); !strings.Contains(body, ": heartbeat\n\n") {
+		t.Errorf("Expected a heartbeat, got %q", body)
+	}
+}
@@ -1,14 +1,20 @@
 package httpserver
 
 import (
-	"encoding/json"
+	"bytes"
 	"net/http"
 	"reflect"
 
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/logger"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// Counter200, Counter201 and Counter204 count success responses on the global registry.
+//
+// Deprecated: use Metrics, which is registered on a caller-provided registry.
 var (
 	Counter200 = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "api_200_success_count",
@@ -29,9 +35,16 @@ type SuccessResponse[T any] struct {
 }
 
 type successResponse struct {
-	Data any `json:"data"`
+	Data any `json:"data" xml:"data"`
 }
 
+// Success writes data with the given status, 200 by default, in the format
+// negotiated from the Accept header (see RegisterEncoder), with Vary: Accept.
+// Data the negotiated format cannot encode, such as maps in XML, fails with an
+// Internal error instead. A paginated data, such as prim.PaginatedData, also
+// gets first/prev/next/last Link headers.
+// Conditional GETs are answered with 304 Not Modified when the response has
+// an ETag or Last-Modified (see SetETag, SetLastModified and ETags).
 func Success(data any, w http.ResponseWriter, r *http.Request, status ...int) http.ResponseWriter {
 	var statusCode int
 	if len(status) == 0 {
@@ -40,24 +53,23 @@ func Success(data any, w http.ResponseWriter, r *http.Request, status ...int) ht
 		statusCode = status[0]
 	}
 
-	switch statusCode {
-	case 201:
-		Counter201.Inc()
-		NewLogger(r, 201).Info()
-	case 204:
+	if statusCode == http.StatusNoContent {
 		Counter204.Inc()
-		NewLogger(r, 204).Info()
-	default:
-		Counter200.Inc()
+		if recordResponse(r, nil, logger.LevelInfo) {
+			logRequest(r, logger.LevelInfo, "", 204, nil)
+		}
+		w.WriteHeader(statusCode)
+		return w
 	}
 
-	w.WriteHeader(statusCode)
-	if statusCode == http.StatusNoContent {
+	// The body depends on Accept, so shared caches must tell formats apart.
+	w.Header().Add("Vary", "Accept")
+	mediaType, encode, ok := negotiateEncoder(r)
+	if !ok {
+		Error(apperr.New(apperr.Request, apperr.NotAcceptable, "none of the accepted media types can be produced", apperr.WithStatus(http.StatusNotAcceptable)), w, r)
 		return w
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
 	var res any
 
 	dataV := reflect.Indirect(reflect.ValueOf(data))
@@ -76,7 +88,36 @@ func Success(data any, w http.ResponseWriter, r *http.Request, status ...int) ht
 		res = successResponse{data}
 	}
 
-	json.NewEncoder(w).Encode(res)
+	// Encode before writing the header, so that a failure can still be
+	// answered with an error instead of a truncated success.
+	var body bytes.Buffer
+	if err := encode(&body, res); err != nil {
+		Error(apperr.Wrap(err, apperr.Internal, apperr.Unexpected, "unable to encode the response as "+mediaType), w, r)
+		return w
+	}
+
+	shouldLog := recordResponse(r, nil, logger.LevelInfo)
+
+	switch statusCode {
+	case 201:
+		Counter201.Inc()
+		if shouldLog {
+			logRequest(r, logger.LevelInfo, "", 201, nil)
+		}
+	default:
+		Counter200.Inc()
+	}
+
+	w.Header().Set("Content-Type", contentTypeOf(mediaType))
+	setPageLinks(w, r, data)
+
+	if isCacheable(r, statusCode, w.Header()) {
+		writeCacheable(w, r, statusCode, body.Bytes())
+		return w
+	}
+
+	w.WriteHeader(statusCode)
+	w.Write(body.Bytes())
 
 	return w
 }
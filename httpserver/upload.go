@@ -0,0 +1,226 @@
+package httpserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/media"
+)
+
+// DefaultMaxFileSize is the largest file Uploads accepts unless set in
+// UploadOptions.
+const DefaultMaxFileSize = 10 << 20
+
+type UploadOptions struct {
+	// Fields are the form fields files may be sent in. Empty accepts any.
+	Fields []string
+	// MaxFiles limits the number of files. Zero means no limit.
+	MaxFiles int
+	// MaxFileSize limits the size of each file. Zero means DefaultMaxFileSize.
+	MaxFileSize int64
+	// MaxTotalSize limits the whole body. Zero means the Binder's MaxBodySize.
+	MaxTotalSize int64
+	// AllowedTypes are the accepted MIME types, sniffed from the file content,
+	// e.g. "image/png" or "image/*". Empty accepts any.
+	AllowedTypes []string
+}
+
+// Upload is a file being streamed from a multipart body. It is only readable
+// inside the callback of Binder.Uploads.
+type Upload struct {
+	// Field is the form field the file was sent in.
+	Field string
+	// Filename is the base name of the file as sent by the client.
+	Filename string
+	// MIME is the type sniffed from the file content.
+	MIME string
+
+	r       io.Reader
+	size    int64
+	maxSize int64
+	err     error
+}
+
+// Read reads the file, failing with BODY_TOO_LARGE once it exceeds the
+// maximum file size.
+func (u *Upload) Read(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	n, err := u.r.Read(p)
+	u.size += int64(n)
+	if u.size > u.maxSize {
+		u.err = fieldError(apperr.BodyTooLarge, http.StatusRequestEntityTooLarge, u.Field,
+			fmt.Sprintf("file %q exceeds %d bytes", u.Filename, u.maxSize))
+		return n - int(u.size-u.maxSize), u.err
+	}
+	return n, err
+}
+
+// Size returns the number of bytes read so far. Once the Uploads callback
+// has returned, it is the file size.
+func (u *Upload) Size() int64 {
+	return u.size
+}
+
+// Media returns the file as a *media.Media, streamed to svc if it is a
+// media.ReaderService. Its URL must be requested inside the Uploads callback.
+func (u *Upload) Media(svc media.MediaService) *media.Media {
+	return media.NewFromReader(u, u.MIME, svc)
+}
+
+// Uploads streams the files of a multipart/form-data body to fn, one at a
+// time and without buffering them, enforcing the limits of opts. Text fields
+// are kept for FormValue. Limits are reported as BODY_TOO_LARGE (413), files
+// of a type not allowed as UNSUPPORTED_MEDIA_TYPE (415) and unexpected or
+// extra files as INVALID_FORM_VALUE, all with the field in the details. An
+// error returned by fn stops reading and is kept as is.
+//
+//	b.Uploads(opts, func(f *httpserver.Upload) error {
+//		url, err := f.Media(svc).URL()
+//		...
+//	})
+func (b *Binder) Uploads(opts UploadOptions, fn func(f *Upload) error) *Binder {
+	if b.err != nil {
+		return b
+	}
+
+	maxTotal := opts.MaxTotalSize
+	if maxTotal == 0 {
+		maxTotal = b.maxBodySize
+	}
+	if maxTotal > 0 {
+		b.r.Body = http.MaxBytesReader(nil, b.r.Body, maxTotal)
+	}
+	maxFile := opts.MaxFileSize
+	if maxFile == 0 {
+		maxFile = DefaultMaxFileSize
+	}
+
+	mr, err := b.r.MultipartReader()
+	if err != nil {
+		b.err = bodyError(err)
+		return b
+	}
+
+	form := url.Values{}
+	files := 0
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.err = bodyError(err)
+			return b
+		}
+
+		field := part.FormName()
+		if part.FileName() == "" {
+			val, err := io.ReadAll(part)
+			if err != nil {
+				b.err = bodyError(err)
+				return b
+			}
+			form.Add(field, string(val))
+			continue
+		}
+
+		if len(opts.Fields) > 0 && !slices.Contains(opts.Fields, field) {
+			b.err = fieldError(apperr.InvalidFormValue, http.StatusBadRequest, field, "files are not accepted in this field")
+			return b
+		}
+		files++
+		if opts.MaxFiles > 0 && files > opts.MaxFiles {
+			b.err = fieldError(apperr.InvalidFormValue, http.StatusBadRequest, field,
+				fmt.Sprintf("no more than %d files are accepted", opts.MaxFiles))
+			return b
+		}
+
+		upload, err := newUpload(part, field, part.FileName(), maxFile)
+		if err != nil {
+			b.err = bodyError(err)
+			return b
+		}
+		if !allowedType(upload.MIME, opts.AllowedTypes) {
+			b.err = fieldError(apperr.UnsupportedMediaType, http.StatusUnsupportedMediaType, field,
+				fmt.Sprintf("files of type %s are not accepted", upload.MIME))
+			return b
+		}
+
+		if err := fn(upload); err != nil {
+			b.err = uploadError(upload, err)
+			return b
+		}
+		// Read what fn left, so that the size limit holds however it reads.
+		if _, err := io.Copy(io.Discard, upload); err != nil {
+			b.err = uploadError(upload, bodyError(err))
+			return b
+		}
+		part.Close()
+	}
+
+	b.r.PostForm = form
+	return b
+}
+
+// newUpload sniffs the type of the file read from r.
+func newUpload(r io.Reader, field, filename string, maxSize int64) (*Upload, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	u := &Upload{
+		Field:    field,
+		Filename: filename,
+		MIME:     mt,
+		r:        io.MultiReader(bytes.NewReader(head), r),
+		maxSize:  maxSize,
+	}
+	return u, nil
+}
+
+func allowedType(mt string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if mediaRangeMatches(a, mt) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadError returns the error to keep when fn failed: the limit error of
+// the file if fn failed because of it, a body error if reading failed, or err.
+func uploadError(u *Upload, err error) error {
+	if u.err != nil {
+		return u.err
+	}
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return bodyError(err)
+	}
+	return err
+}
+
+// fieldError returns a Request error for a form field.
+func fieldError(code apperr.Code, status int, field, detail string) error {
+	return apperr.Wrap(
+		apperr.NewMapError(map[string]string{field: detail}),
+		apperr.Request, code, detail,
+		apperr.WithStatus(status), apperr.WithMeta("param", field),
+	)
+}
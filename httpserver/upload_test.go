@@ -0,0 +1,215 @@
+package httpserver_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+	"github.com/kgjoner/cornucopia/v3/httpserver"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+type uploadPart struct {
+	field, filename string
+	content         []byte
+}
+
+func multipartRequest(t *testing.T, parts ...uploadPart) *http.Request {
+	t.Helper()
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+	for _, p := range parts {
+		var w io.Writer
+		var err error
+		if p.filename == "" {
+			w, err = writer.CreateFormField(p.field)
+		} else {
+			w, err = writer.CreateFormFile(p.field, p.filename)
+		}
+		if err != nil {
+			t.Fatalf("Failed to create part: %v", err)
+		}
+		w.Write(p.content)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &b)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+type readerMediaService struct {
+	mockMediaService
+	stored map[string][]byte
+}
+
+func (s *readerMediaService) StoreReader(file io.Reader, mime, kind, id string) (string, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	s.stored[id] = content
+	return "http://example.com/" + id, nil
+}
+
+func TestUploads(t *testing.T) {
+	png := append(bytes.Clone(pngHeader), bytes.Repeat([]byte{0}, 600)...)
+	req := multipartRequest(t,
+		uploadPart{field: "title", content: []byte("Holiday")},
+		uploadPart{field: "photos", filename: "a.png", content: png},
+		uploadPart{field: "photos", filename: "../b.png", content: pngHeader},
+	)
+	svc := &readerMediaService{stored: map[string][]byte{}}
+
+	type file struct {
+		field, filename, mime, url string
+		size                       int64
+	}
+	var files []file
+	var title string
+	err := httpserver.Bind(req).
+		Uploads(httpserver.UploadOptions{Fields: []string{"photos"}, AllowedTypes: []string{"image/*"}}, func(f *httpserver.Upload) error {
+			m := f.Media(svc)
+			m.Config(f.Filename, "photo")
+			url, err := m.URL()
+			files = append(files, file{f.Field, f.Filename, f.MIME, url, f.Size()})
+			return err
+		}).
+		FormValue("title", &title).
+		Err()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if title != "Holiday" {
+		t.Errorf("Expected title 'Holiday', got %q", title)
+	}
+	want := []file{
+		{"photos", "a.png", "image/png", "http://example.com/a.png", int64(len(png))},
+		{"photos", "b.png", "image/png", "http://example.com/b.png", int64(len(pngHeader))},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("Expected %d files, got %+v", len(want), files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], files[i])
+		}
+	}
+	if !bytes.Equal(svc.stored["a.png"], png) {
+		t.Errorf("Expected a.png to be stored whole, got %d bytes", len(svc.stored["a.png"]))
+	}
+}
+
+func TestUploadsLimits(t *testing.T) {
+	store := func(f *httpserver.Upload) error {
+		_, err := io.Copy(io.Discard, f)
+		return err
+	}
+
+	tests := []struct {
+		name   string
+		parts  []uploadPart
+		opts   httpserver.UploadOptions
+		status int
+		code   apperr.Code
+	}{
+		{
+			name:   "file too large",
+			parts:  []uploadPart{{field: "doc", filename: "a.txt", content: []byte(strings.Repeat("a", 100))}},
+			opts:   httpserver.UploadOptions{MaxFileSize: 10},
+			status: http.StatusRequestEntityTooLarge,
+			code:   apperr.BodyTooLarge,
+		},
+		{
+			name:   "body too large",
+			parts:  []uploadPart{{field: "doc", filename: "a.txt", content: []byte(strings.Repeat("a", 1000))}},
+			opts:   httpserver.UploadOptions{MaxTotalSize: 200},
+			status: http.StatusRequestEntityTooLarge,
+			code:   apperr.BodyTooLarge,
+		},
+		{
+			name:   "type not allowed",
+			parts:  []uploadPart{{field: "doc", filename: "a.png", content: []byte("plain text")}},
+			opts:   httpserver.UploadOptions{AllowedTypes: []string{"image/png"}},
+			status: http.StatusUnsupportedMediaType,
+			code:   apperr.UnsupportedMediaType,
+		},
+		{
+			name: "too many files",
+			parts: []uploadPart{
+				{field: "doc", filename: "a.txt", content: []byte("a")},
+				{field: "doc", filename: "b.txt", content: []byte("b")},
+			},
+			opts:   httpserver.UploadOptions{MaxFiles: 1},
+			status: http.StatusBadRequest,
+			code:   apperr.InvalidFormValue,
+		},
+		{
+			name:   "unexpected field",
+			parts:  []uploadPart{{field: "other", filename: "a.txt", content: []byte("a")}},
+			opts:   httpserver.UploadOptions{Fields: []string{"doc"}},
+			status: http.StatusBadRequest,
+			code:   apperr.InvalidFormValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := httpserver.Bind(multipartRequest(t, tt.parts...)).Uploads(tt.opts, store).Err()
+			status, res := bindErrorResponse(t, err)
+
+			if status != tt.status || res["code"] != string(tt.code) {
+				t.Errorf("Expected %d %s, got %d %v", tt.status, tt.code, status, res)
+			}
+		})
+	}
+}
+
+func TestUploadsKeepsCallbackError(t *testing.T) {
+	failure := errors.New("storage down")
+	req := multipartRequest(t, uploadPart{field: "doc", filename: "a.txt", content: []byte("a")})
+
+	err := httpserver.Bind(req).Uploads(httpserver.UploadOptions{}, func(f *httpserver.Upload) error {
+		return failure
+	}).Err()
+
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the callback error, got %v", err)
+	}
+}
+
+func TestUploadsLimitsUnreadFiles(t *testing.T) {
+	req := multipartRequest(t, uploadPart{field: "file", filename: "big.bin", content: bytes.Repeat([]byte{1}, 5000)})
+
+	var upload *httpserver.Upload
+	err := httpserver.Bind(req).
+		Uploads(httpserver.UploadOptions{MaxFileSize: 1000}, func(f *httpserver.Upload) error {
+			upload = f
+			return nil
+		}).
+		Err()
+
+	var e *apperr.AppError
+	if !errors.As(err, &e) || e.Code != apperr.BodyTooLarge {
+		t.Fatalf("Expected BODY_TOO_LARGE for an unread file, got %v", err)
+	}
+
+	req = multipartRequest(t, uploadPart{field: "file", filename: "small.bin", content: bytes.Repeat([]byte{1}, 700)})
+	err = httpserver.Bind(req).
+		Uploads(httpserver.UploadOptions{MaxFileSize: 1000}, func(f *httpserver.Upload) error {
+			upload = f
+			return nil
+		}).
+		Err()
+	if err != nil || upload.Size() != 700 {
+		t.Errorf("Expected the size of an unread file, got %d, %v", upload.Size(), err)
+	}
+}
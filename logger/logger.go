@@ -0,0 +1,140 @@
+// Package logger defines the small logging interface used across cornucopia,
+// with adapters for log/slog and logrus.
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	// LevelFatal marks entries of fatal severity. Adapters never exit the process.
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	}
+	return "unknown"
+}
+
+// Fields are structured key/value pairs attached to an entry.
+type Fields map[string]any
+
+// Logger writes structured log entries.
+type Logger interface {
+	Log(ctx context.Context, level Level, msg string, fields Fields)
+}
+
+// Func adapts a function to Logger, e.g. to capture entries in tests.
+type Func func(ctx context.Context, level Level, msg string, fields Fields)
+
+func (f Func) Log(ctx context.Context, level Level, msg string, fields Fields) {
+	f(ctx, level, msg, fields)
+}
+
+/* ==============================================================================
+	 slog
+============================================================================== */
+
+// SlogLevelFatal is the slog level used for LevelFatal entries.
+const SlogLevelFatal = slog.LevelError + 4
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// FromSlog adapts an *slog.Logger. A nil l uses slog.Default() at log time.
+func FromSlog(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+func (s slogLogger) Log(ctx context.Context, level Level, msg string, fields Fields) {
+	l := s.l
+	if l == nil {
+		l = slog.Default()
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, len(keys))
+	for i, k := range keys {
+		attrs[i] = slog.Any(k, fields[k])
+	}
+	l.LogAttrs(ctx, slogLevel(level), msg, attrs...)
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelFatal:
+		return SlogLevelFatal
+	default:
+		return slog.LevelError
+	}
+}
+
+/* ==============================================================================
+	 logrus
+============================================================================== */
+
+type logrusLogger struct {
+	l logrus.FieldLogger
+}
+
+// FromLogrus adapts a logrus logger or entry. A nil l uses the global logrus
+// logger. Fatal entries are logged at logrus.FatalLevel without exiting.
+func FromLogrus(l logrus.FieldLogger) Logger {
+	return logrusLogger{l}
+}
+
+func (s logrusLogger) Log(ctx context.Context, level Level, msg string, fields Fields) {
+	l := s.l
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	l.WithFields(logrus.Fields(fields)).WithContext(ctx).Log(logrusLevel(level), msg)
+}
+
+func logrusLevel(level Level) logrus.Level {
+	switch level {
+	case LevelDebug:
+		return logrus.DebugLevel
+	case LevelInfo:
+		return logrus.InfoLevel
+	case LevelWarn:
+		return logrus.WarnLevel
+	case LevelFatal:
+		return logrus.FatalLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
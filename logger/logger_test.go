@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	l := FromSlog(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	l.Log(context.Background(), LevelWarn, "slow query", Fields{"Table": "orders", "Ms": 250})
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, `msg="slow query"`) {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if !strings.Contains(out, "Ms=250 Table=orders") {
+		t.Fatalf("expected sorted fields, got %q", out)
+	}
+
+	buf.Reset()
+	l.Log(context.Background(), LevelFatal, "boom", nil)
+	if !strings.Contains(buf.String(), "level=ERROR+4") {
+		t.Fatalf("unexpected fatal output: %q", buf.String())
+	}
+}
+
+func TestFromLogrus(t *testing.T) {
+	base, hook := test.NewNullLogger()
+	l := FromLogrus(base)
+
+	l.Log(context.Background(), LevelFatal, "boom", Fields{"Code": "UNEXPECTED"})
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Level != logrus.FatalLevel || entry.Message != "boom" || entry.Data["Code"] != "UNEXPECTED" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+}
+
+func TestFunc(t *testing.T) {
+	var got Level
+	var l Logger = Func(func(ctx context.Context, level Level, msg string, fields Fields) {
+		got = level
+	})
+
+	l.Log(context.Background(), LevelError, "x", nil)
+	if got != LevelError {
+		t.Fatalf("expected LevelError, got %s", got)
+	}
+}
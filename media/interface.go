@@ -2,8 +2,16 @@ package media
 
 import (
 	"bytes"
+	"io"
 )
 
 type MediaService interface {
 	Store(file *bytes.Buffer, kind, id string) (string, error)
 }
+
+// ReaderService is a MediaService that can also store a file read from a
+// reader, so that uploads need not be buffered in memory.
+type ReaderService interface {
+	MediaService
+	StoreReader(file io.Reader, mime, kind, id string) (string, error)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"io"
 	"net/http"
 	"reflect"
 	"strings"
@@ -16,6 +17,8 @@ type Media struct {
 	file         *bytes.Buffer
 	mediaService MediaService
 	mime         string
+	// Set instead of file for media created from a reader, until it is stored
+	reader io.Reader
 	// May be used as reference in URL by media service
 	id string
 	// May be used for adding custom settings by media service
@@ -30,6 +33,17 @@ func New(file *bytes.Buffer, mediaService MediaService) *Media {
 	}
 }
 
+// NewFromReader creates a Media whose file is read from file only when stored.
+// mime is the type of the file, as sniffed by the caller. The file is streamed
+// to mediaService if it is a ReaderService, and buffered otherwise.
+func NewFromReader(file io.Reader, mime string, mediaService MediaService) *Media {
+	return &Media{
+		reader:       file,
+		mediaService: mediaService,
+		mime:         mime,
+	}
+}
+
 func (m *Media) IsValid() error {
 	if m.url == "" && (m.IsEmpty() || reflect.ValueOf(m.mediaService).IsZero()) {
 		return apperr.NewValidationError("missing fields in media type")
@@ -43,6 +57,9 @@ func (m Media) IsZero() bool {
 }
 
 func (m Media) IsEmpty() bool {
+	if m.reader != nil {
+		return false
+	}
 	return m.file == nil || m.file.Len() == 0
 }
 
@@ -62,7 +79,14 @@ func (m *Media) URL() (string, error) {
 		return "", apperr.NewValidationError("missing fields in media type")
 	}
 
-	url, err := m.mediaService.Store(m.file, m.kind, m.id)
+	var url string
+	var err error
+	if rs, ok := m.mediaService.(ReaderService); ok && m.reader != nil {
+		url, err = rs.StoreReader(m.reader, m.mime, m.kind, m.id)
+		m.reader = nil
+	} else if err = m.buffer(); err == nil {
+		url, err = m.mediaService.Store(m.file, m.kind, m.id)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -71,6 +95,17 @@ func (m *Media) URL() (string, error) {
 	return url, nil
 }
 
+// buffer reads the file of a media created from a reader into memory.
+func (m *Media) buffer() error {
+	if m.reader == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, m.reader)
+	m.file, m.reader = &buf, nil
+	return err
+}
+
 // Check if media is an image type.
 func (m *Media) IsImage() bool {
 	return strings.Contains(m.mime, "image")
@@ -82,6 +117,10 @@ func (m *Media) Shape() (width int, height int, err error) {
 		return 0, 0, fmt.Errorf("must be an image to get its shape")
 	}
 
+	if err := m.buffer(); err != nil {
+		return 0, 0, err
+	}
+
 	fileCopy := bytes.NewReader(m.file.Bytes())
 	img, _, err := image.Decode(fileCopy)
 	if err != nil {
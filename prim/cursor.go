@@ -0,0 +1,173 @@
+package prim
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+var cursorSecret atomic.Pointer[[]byte]
+
+func init() {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	cursorSecret.Store(&secret)
+}
+
+// SetCursorSecret sets the key cursors are signed with. It defaults to a
+// random key, so cursors are only valid within the process that issued them
+// until a key shared by every instance is set.
+func SetCursorSecret(secret []byte) {
+	secret = append([]byte(nil), secret...)
+	cursorSecret.Store(&secret)
+}
+
+// Cursor is a position in a keyset-paginated list: the sort keys of a row,
+// and whether to read the rows after it or, when Backward, before it. It is
+// sent to clients as an opaque, signed string.
+type Cursor struct {
+	keys     []json.RawMessage
+	Backward bool
+}
+
+// NewCursor creates a cursor after the row with the given sort keys, in
+// ORDER BY order. The keys must be JSON-marshalable.
+func NewCursor(keys ...any) (*Cursor, error) {
+	c := &Cursor{keys: make([]json.RawMessage, len(keys))}
+	for i, key := range keys {
+		raw, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		c.keys[i] = raw
+	}
+	return c, nil
+}
+
+// Reversed returns a copy of c reading in the other direction.
+func (c Cursor) Reversed() *Cursor {
+	c.Backward = !c.Backward
+	return &c
+}
+
+// Scan copies the sort keys of c into dest, in ORDER BY order.
+func (c *Cursor) Scan(dest ...any) error {
+	if len(dest) != len(c.keys) {
+		return apperr.NewValidationError(fmt.Sprintf("cursor has %d keys, not %d", len(c.keys), len(dest)))
+	}
+	for i, raw := range c.keys {
+		if err := json.Unmarshal(raw, dest[i]); err != nil {
+			return apperr.NewValidationError("invalid cursor")
+		}
+	}
+	return nil
+}
+
+type cursorPayload struct {
+	Keys     []json.RawMessage `json:"k"`
+	Backward bool              `json:"b,omitempty"`
+}
+
+// Encode returns the signed, URL-safe form of c.
+func (c Cursor) Encode() string {
+	payload, _ := json.Marshal(cursorPayload{c.keys, c.Backward})
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(signCursor(payload))
+}
+
+// DecodeCursor parses a cursor returned by Encode, checking its signature.
+func DecodeCursor(str string) (*Cursor, error) {
+	enc := base64.RawURLEncoding
+	payloadStr, sigStr, found := strings.Cut(str, ".")
+	if !found {
+		return nil, apperr.NewValidationError("invalid cursor")
+	}
+	payload, err := enc.DecodeString(payloadStr)
+	if err != nil {
+		return nil, apperr.NewValidationError("invalid cursor")
+	}
+	sig, err := enc.DecodeString(sigStr)
+	if err != nil || !hmac.Equal(sig, signCursor(payload)) {
+		return nil, apperr.NewValidationError("invalid cursor")
+	}
+
+	var p cursorPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, apperr.NewValidationError("invalid cursor")
+	}
+	return &Cursor{keys: p.Keys, Backward: p.Backward}, nil
+}
+
+func signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, *cursorSecret.Load())
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (c Cursor) String() string {
+	return c.Encode()
+}
+
+func (c Cursor) MarshalText() ([]byte, error) {
+	return []byte(c.Encode()), nil
+}
+
+func (c *Cursor) UnmarshalText(text []byte) error {
+	parsed, err := DecodeCursor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = *parsed
+	return nil
+}
+
+// CursorPagination is the keyset counterpart of Pagination. A nil Cursor
+// reads the first page.
+type CursorPagination struct {
+	Cursor *Cursor `json:"cursor"`
+	Limit  int     `json:"limit"`
+}
+
+func NewCursorPagination(cursor *Cursor, limit int) *CursorPagination {
+	if limit == 0 {
+		limit = 20
+	}
+
+	return &CursorPagination{
+		Cursor: cursor,
+		Limit:  limit,
+	}
+}
+
+// IsBackward reports whether the page is read backward, from a prevCursor.
+func (p CursorPagination) IsBackward() bool {
+	return p.Cursor != nil && p.Cursor.Backward
+}
+
+type CursorPaginatedData[T any] struct {
+	Data       []T     `json:"data"`
+	Limit      int     `json:"limit"`
+	NextCursor *Cursor `json:"nextCursor"`
+	PrevCursor *Cursor `json:"prevCursor"`
+}
+
+func TransformCursorPaginatedData[K, T any](data *CursorPaginatedData[T], transformer func(data T) K) *CursorPaginatedData[K] {
+	outputData := []K{}
+	for _, value := range data.Data {
+		outputData = append(outputData, transformer(value))
+	}
+
+	return &CursorPaginatedData[K]{
+		Data:       outputData,
+		Limit:      data.Limit,
+		NextCursor: data.NextCursor,
+		PrevCursor: data.PrevCursor,
+	}
+}
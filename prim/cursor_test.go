@@ -0,0 +1,59 @@
+package prim_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	cursor, err := prim.NewCursor(createdAt, int64(9007199254740993))
+	assert.Nil(t, err)
+
+	decoded, err := prim.DecodeCursor(cursor.Encode())
+	assert.Nil(t, err)
+	assert.False(t, decoded.Backward)
+
+	var gotCreatedAt time.Time
+	var gotID int64
+	assert.Nil(t, decoded.Scan(&gotCreatedAt, &gotID))
+	assert.True(t, createdAt.Equal(gotCreatedAt))
+	assert.Equal(t, int64(9007199254740993), gotID)
+
+	assert.NotNil(t, decoded.Scan(&gotID))
+
+	reversed, err := prim.DecodeCursor(cursor.Reversed().Encode())
+	assert.Nil(t, err)
+	assert.True(t, reversed.Backward)
+}
+
+func TestCursorRejectsTampering(t *testing.T) {
+	prim.SetCursorSecret([]byte("another key"))
+	forged, _ := prim.NewCursor(2)
+	forgedEncoded := forged.Encode()
+
+	prim.SetCursorSecret([]byte("test key"))
+	cursor, _ := prim.NewCursor(1)
+	encoded := cursor.Encode()
+
+	_, err := prim.DecodeCursor(encoded)
+	assert.Nil(t, err)
+
+	for _, str := range []string{"", "abc", encoded + "x", forgedEncoded} {
+		_, err := prim.DecodeCursor(str)
+		assert.NotNil(t, err, str)
+	}
+}
+
+func TestCursorPaginatedDataJSON(t *testing.T) {
+	next, _ := prim.NewCursor("b")
+	data := prim.CursorPaginatedData[string]{Data: []string{"a"}, Limit: 1, NextCursor: next}
+
+	raw, err := json.Marshal(data)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"data":["a"],"limit":1,"nextCursor":"`+next.Encode()+`","prevCursor":null}`, string(raw))
+}
@@ -31,6 +31,9 @@ type PaginatedData[T any] struct {
 	Page    int  `json:"page"`
 	Limit   int  `json:"limit"`
 	HasNext bool `json:"hasNext"`
+	// Total and TotalPages are nil when the total count is unknown.
+	Total      *int `json:"total,omitempty"`
+	TotalPages *int `json:"totalPages,omitempty"`
 }
 
 func NewPaginatedData[T any](p Pagination, data []T) *PaginatedData[T] {
@@ -42,6 +45,33 @@ func NewPaginatedData[T any](p Pagination, data []T) *PaginatedData[T] {
 	}
 }
 
+// SetTotal sets the total count of items and the number of pages it makes.
+func (d *PaginatedData[T]) SetTotal(total int) {
+	pages := 0
+	if d.Limit > 0 {
+		pages = (total + d.Limit - 1) / d.Limit
+	}
+	d.Total = &total
+	d.TotalPages = &pages
+}
+
+// PageInfo describes a page of a list, whatever the type of its items.
+type PageInfo struct {
+	Page       int
+	Limit      int
+	HasNext    bool
+	TotalPages *int
+}
+
+func (d PaginatedData[T]) PageInfo() PageInfo {
+	return PageInfo{
+		Page:       d.Page,
+		Limit:      d.Limit,
+		HasNext:    d.HasNext,
+		TotalPages: d.TotalPages,
+	}
+}
+
 func TransformPaginatedData[K, T any](data *PaginatedData[T], transformer func(data T) K) *PaginatedData[K] {
 	outputData := []K{}
 	for _, value := range data.Data {
@@ -49,9 +79,11 @@ func TransformPaginatedData[K, T any](data *PaginatedData[T], transformer func(d
 	}
 
 	return &PaginatedData[K]{
-		Data:    outputData,
-		Page:    data.Page,
-		Limit:   data.Limit,
-		HasNext: data.HasNext,
+		Data:       outputData,
+		Page:       data.Page,
+		Limit:      data.Limit,
+		HasNext:    data.HasNext,
+		Total:      data.Total,
+		TotalPages: data.TotalPages,
 	}
 }
@@ -0,0 +1,25 @@
+package prim_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginatedDataTotal(t *testing.T) {
+	data := prim.NewPaginatedData(prim.Pagination{Page: 2, Limit: 10, HasNext: true}, []int{1})
+
+	raw, err := json.Marshal(data)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"data":[1],"page":2,"limit":10,"hasNext":true}`, string(raw))
+
+	data.SetTotal(115)
+	raw, err = json.Marshal(data)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"data":[1],"page":2,"limit":10,"hasNext":true,"total":115,"totalPages":12}`, string(raw))
+
+	transformed := prim.TransformPaginatedData(data, func(n int) string { return "x" })
+	assert.Equal(t, 12, *transformed.PageInfo().TotalPages)
+}
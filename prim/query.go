@@ -0,0 +1,129 @@
+package prim
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+// SortField orders a list by Field, the name exposed to clients. Column is
+// where it is stored, e.g. a SQL column; it defaults to Field.
+type SortField struct {
+	Field  string `json:"field"`
+	Column string `json:"-"`
+	Desc   bool   `json:"desc"`
+}
+
+// Sort is a list order, most significant field first.
+type Sort []SortField
+
+// ParseSort parses comma-separated fields, each prefixed with "-" for a
+// descending order, e.g. "-createdAt,name".
+func ParseSort(str string) (Sort, error) {
+	if str == "" {
+		return nil, nil
+	}
+
+	var sort Sort
+	for _, part := range strings.Split(str, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		field := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+		if field == "" {
+			return nil, apperr.NewValidationError(fmt.Sprintf("invalid sort %q", str))
+		}
+		sort = append(sort, SortField{Field: field, Column: field, Desc: desc})
+	}
+	return sort, nil
+}
+
+func (s Sort) String() string {
+	parts := make([]string, len(s))
+	for i, f := range s {
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		} else {
+			parts[i] = f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+type FilterOp string
+
+const (
+	OpEq    FilterOp = "eq"
+	OpNe    FilterOp = "ne"
+	OpGt    FilterOp = "gt"
+	OpGte   FilterOp = "gte"
+	OpLt    FilterOp = "lt"
+	OpLte   FilterOp = "lte"
+	OpIn    FilterOp = "in"
+	OpNin   FilterOp = "nin"
+	OpLike  FilterOp = "like"
+	OpExist FilterOp = "exists"
+)
+
+func (o FilterOp) Enumerate() any {
+	return []FilterOp{
+		OpEq,
+		OpNe,
+		OpGt,
+		OpGte,
+		OpLt,
+		OpLte,
+		OpIn,
+		OpNin,
+		OpLike,
+		OpExist,
+	}
+}
+
+// IsMulti reports whether the operator takes a list of values.
+func (o FilterOp) IsMulti() bool {
+	return o == OpIn || o == OpNin
+}
+
+// Filter is a condition on Field, the name exposed to clients. Column is
+// where it is stored, e.g. a SQL column; it defaults to Field. Values hold
+// one value, or several for in/nin. They are strings as parsed, until typed
+// by the caller (see httpserver.Binder.SortAndFilter). exists takes a bool.
+type Filter struct {
+	Field  string   `json:"field"`
+	Column string   `json:"-"`
+	Op     FilterOp `json:"op"`
+	Values []any    `json:"values"`
+}
+
+// Filters are conditions that must all hold.
+type Filters []Filter
+
+// ParseFilter parses an "op:value" expression on field, e.g. "gte:100" or
+// "in:active,pending". Without a known operator prefix, the whole expression
+// is the value of an eq filter.
+func ParseFilter(field, expr string) (Filter, error) {
+	op, value := OpEq, expr
+	if prefix, rest, found := strings.Cut(expr, ":"); found {
+		for _, known := range OpEq.Enumerate().([]FilterOp) {
+			if FilterOp(prefix) == known {
+				op, value = known, rest
+				break
+			}
+		}
+	}
+
+	var values []any
+	if op.IsMulti() {
+		for _, v := range strings.Split(value, ",") {
+			values = append(values, strings.TrimSpace(v))
+		}
+	} else {
+		values = []any{value}
+	}
+	if value == "" {
+		return Filter{}, apperr.NewValidationError(fmt.Sprintf("missing value for %s filter", op))
+	}
+
+	return Filter{Field: field, Column: field, Op: op, Values: values}, nil
+}
@@ -0,0 +1,41 @@
+package prim_test
+
+import (
+	"testing"
+
+	"github.com/kgjoner/cornucopia/v3/prim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSort(t *testing.T) {
+	sort, err := prim.ParseSort("-createdAt, name")
+	assert.Nil(t, err)
+	assert.Equal(t, prim.Sort{
+		{Field: "createdAt", Column: "createdAt", Desc: true},
+		{Field: "name", Column: "name"},
+	}, sort)
+	assert.Equal(t, "-createdAt,name", sort.String())
+
+	_, err = prim.ParseSort("name,,-")
+	assert.NotNil(t, err)
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := prim.ParseFilter("status", "in:active, pending")
+	assert.Nil(t, err)
+	assert.Equal(t, prim.OpIn, filter.Op)
+	assert.Equal(t, []any{"active", "pending"}, filter.Values)
+
+	filter, err = prim.ParseFilter("price", "gte:100")
+	assert.Nil(t, err)
+	assert.Equal(t, prim.OpGte, filter.Op)
+	assert.Equal(t, []any{"100"}, filter.Values)
+
+	filter, err = prim.ParseFilter("time", "10:30")
+	assert.Nil(t, err)
+	assert.Equal(t, prim.OpEq, filter.Op)
+	assert.Equal(t, []any{"10:30"}, filter.Values)
+
+	_, err = prim.ParseFilter("price", "lt:")
+	assert.NotNil(t, err)
+}
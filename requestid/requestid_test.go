@@ -0,0 +1,34 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("expected no request ID in empty context")
+	}
+
+	ctx := NewContext(context.Background(), "abc-123")
+	if id, ok := FromContext(ctx); !ok || id != "abc-123" {
+		t.Fatalf("unexpected request ID: %q, %v", id, ok)
+	}
+}
+
+func TestNewAndIsValid(t *testing.T) {
+	a, b := New(), New()
+	if len(a) != 32 || a == b {
+		t.Fatalf("expected distinct 32-char IDs, got %q and %q", a, b)
+	}
+	if !IsValid(a) {
+		t.Fatalf("expected generated ID to be valid")
+	}
+
+	for _, id := range []string{"", "has space", "line\nbreak", strings.Repeat("a", 129)} {
+		if IsValid(id) {
+			t.Errorf("expected %q to be invalid", id)
+		}
+	}
+}
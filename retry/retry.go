@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+// Policy configures Do. Zero fields fall back to defaults.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first. Default 3.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt, doubled on each retry. Default 100ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. Default 5s.
+	MaxDelay time.Duration
+	// ShouldRetry decides whether an error is worth retrying. Default apperr.IsRetryable.
+	ShouldRetry func(error) bool
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 100 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 5 * time.Second
+	}
+	if p.ShouldRetry == nil {
+		p.ShouldRetry = apperr.IsRetryable
+	}
+	return p
+}
+
+// Do runs fn until it succeeds, returns an error that is not retryable, the
+// attempts are exhausted or ctx is done. Retries wait an exponential backoff
+// with full jitter, or longer if the error hints a delay via apperr.RetryableAfter.
+// The last error is returned.
+func Do[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	policy = policy.withDefaults()
+
+	var result T
+	var err error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		result, err = fn(ctx)
+		if err == nil || !policy.ShouldRetry(err) || attempt == policy.MaxAttempts-1 {
+			return result, err
+		}
+
+		delay := policy.backoff(attempt)
+		if hint, ok := apperr.RetryDelay(err); ok && hint > delay {
+			delay = hint
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+	return result, err
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay*2^attempt)).
+func (p Policy) backoff(attempt int) time.Duration {
+	ceiling := p.MaxDelay
+	if attempt < 32 {
+		if d := p.BaseDelay << attempt; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	return rand.N(ceiling)
+}
@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kgjoner/cornucopia/v3/apperr"
+)
+
+var fastPolicy = Policy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestDoRetriesRetryableErrors(t *testing.T) {
+	attempts := 0
+	got, err := Do(context.Background(), fastPolicy, func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts < 3 {
+			return "", apperr.NewExternalError("upstream down", apperr.NetworkConnection)
+		}
+		return "ok", nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if got != "ok" || attempts != 3 {
+		t.Fatalf("unexpected result %q after %d attempts", got, attempts)
+	}
+}
+
+func TestDoStopsOnNonRetryableError(t *testing.T) {
+	attempts := 0
+	_, err := Do(context.Background(), fastPolicy, func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, apperr.NewValidationError("bad")
+	})
+
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected a single failed attempt, got %d attempts and err %v", attempts, err)
+	}
+}
+
+func TestDoExhaustsAttempts(t *testing.T) {
+	attempts := 0
+	sentinel := apperr.Retryable(errors.New("flaky"))
+	_, err := Do(context.Background(), fastPolicy, func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, sentinel
+	})
+
+	if err != sentinel || attempts != fastPolicy.MaxAttempts {
+		t.Fatalf("expected last error after %d attempts, got %d attempts and err %v", fastPolicy.MaxAttempts, attempts, err)
+	}
+}
+
+func TestDoHonoursContextAndDelayHint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	start := time.Now()
+	_, err := Do(ctx, fastPolicy, func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, apperr.RetryableAfter(errors.New("throttled"), time.Minute)
+	})
+
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected a single attempt, got %d attempts and err %v", attempts, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected context cancellation to interrupt the wait")
+	}
+}
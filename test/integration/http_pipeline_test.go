@@ -12,6 +12,7 @@ import (
 	"github.com/kgjoner/cornucopia/v3/httpclient"
 	"github.com/kgjoner/cornucopia/v3/httpserver"
 	"github.com/kgjoner/cornucopia/v3/prim"
+	"github.com/kgjoner/cornucopia/v3/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -230,3 +231,230 @@ func TestHTTPClientMapsUnauthorizedFromServer(t *testing.T) {
 	assert.Equal(t, apperr.External, appErr.Kind)
 	assert.Equal(t, apperr.Unauthenticated, appErr.Code)
 }
+
+// TestHTTPServerErrorHidesInternalCause verifies that httpserver.Error only
+// exposes the public message and honours an explicit status override.
+func TestHTTPServerErrorHidesInternalCause(t *testing.T) {
+	appErr := apperr.Wrap(errors.New("pq: connection refused"), apperr.Internal, apperr.Unexpected,
+		"could not save order", apperr.WithStatus(http.StatusServiceUnavailable), apperr.WithMeta("orderID", 7))
+	srv := httptest.NewServer(errHandler(appErr))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "could not save order", body["message"])
+	assert.NotContains(t, body, "orderID")
+}
+
+// The codes are registered once for the package, since Register panics on
+// duplicates and tests may run more than once (-count).
+const (
+	integrationPriceCode apperr.Code = "INTEGRATION_PRICE_BELOW_MINIMUM"
+	integrationBatchCode apperr.Code = "INTEGRATION_BATCH_REJECTED"
+)
+
+func init() {
+	apperr.Register(integrationPriceCode, apperr.CodeSpec{
+		Kind:    apperr.Validation,
+		Status:  http.StatusBadRequest,
+		Message: "price below minimum",
+		Messages: map[string]string{
+			string(prim.English):    "Price must be at least {min}",
+			string(prim.Portuguese): "O preço deve ser no mínimo {min}",
+		},
+	})
+	apperr.Register(integrationBatchCode, apperr.CodeSpec{Kind: apperr.Validation, Status: http.StatusUnprocessableEntity})
+}
+
+// TestHTTPServerErrorUsesCodeRegistry verifies that httpserver.Error takes the
+// status from the code registry and localizes the message by Accept-Language.
+func TestHTTPServerErrorUsesCodeRegistry(t *testing.T) {
+	const code = integrationPriceCode
+
+	srv := httptest.NewServer(errHandler(apperr.FromCode(code, apperr.WithMeta("min", 100))))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Language", "pt-BR,en;q=0.8")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, string(code), body["code"])
+	assert.Equal(t, "O preço deve ser no mínimo 100", body["message"])
+}
+
+// TestHTTPServerErrorWithMultiError verifies that an apperr.MultiError is
+// answered with its overall Kind and one entry per failed item.
+func TestHTTPServerErrorWithMultiError(t *testing.T) {
+	errs := apperr.NewMultiError().
+		Add(0, "orders[0]", apperr.NewValidationError("invalid price")).
+		Add(3, "orders[3]", apperr.NewConflictError("duplicate order"))
+
+	srv := httptest.NewServer(errHandler(errs.Err()))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var body struct {
+		Kind   string `json:"kind"`
+		Errors []struct {
+			Index int    `json:"index"`
+			Path  string `json:"path"`
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, string(apperr.Conflict), body.Kind)
+	require.Len(t, body.Errors, 2)
+	assert.Equal(t, 3, body.Errors[1].Index)
+	assert.Equal(t, "orders[3]", body.Errors[1].Path)
+	assert.Equal(t, "duplicate order", body.Errors[1].Error.Message)
+}
+
+// TestHTTPServerProblemDetailsRoundTrip verifies the RFC 9457 renderer output
+// and that httpclient reconstructs the AppError from it.
+func TestHTTPServerProblemDetailsRoundTrip(t *testing.T) {
+	httpserver.SetErrorRenderer(httpserver.ProblemDetailsRenderer("https://errors.example.com"))
+	defer httpserver.SetErrorRenderer(nil)
+
+	type signup struct {
+		Name  string     `validate:"required"`
+		Email prim.Email `validate:"required"`
+	}
+	validationErr := validator.Validate(signup{Name: "Alice"})
+	require.Error(t, validationErr)
+
+	srv := httptest.NewServer(errHandler(validationErr))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/signup")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+
+	var problem map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "https://errors.example.com/invalid-data", problem["type"])
+	assert.Equal(t, "Unprocessable Entity", problem["title"])
+	assert.Equal(t, float64(http.StatusUnprocessableEntity), problem["status"])
+	assert.Equal(t, "invalid field(s)", problem["detail"])
+	assert.Equal(t, "/signup", problem["instance"])
+	assert.Equal(t, map[string]any{"Email": "required"}, problem["errors"])
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/signup", nil)
+	require.NoError(t, err)
+	_, clientErr := httpclient.DoReq(srv.Client(), req, nil)
+
+	var appErr *apperr.AppError
+	require.True(t, errors.As(clientErr, &appErr))
+	assert.Equal(t, apperr.External, appErr.Kind)
+	assert.Equal(t, apperr.InvalidData, appErr.Code)
+	assert.Equal(t, "invalid field(s)", appErr.Message)
+}
+
+// TestRequestIDPropagatesToUpstream verifies that the ID set by the RequestID
+// middleware is forwarded by httpclient to upstream services.
+func TestRequestIDPropagatesToUpstream(t *testing.T) {
+	var upstreamID string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamID = r.Header.Get("X-Request-ID")
+		httpserver.Success("pong", w, r)
+	}))
+	defer upstream.Close()
+
+	client := httpclient.New(upstream.URL)
+	srv := httptest.NewServer(httpserver.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var out map[string]any
+		if _, err := client.Get("/ping", &httpclient.Options{Context: r.Context()})(&out); err != nil {
+			httpserver.Error(err, w, r)
+			return
+		}
+		httpserver.Success(out["data"], w, r)
+	})))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "trace-me")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "trace-me", upstreamID)
+}
+
+// TestHTTPServerErrorWithWrappedMultiError verifies that an AppError wrapping
+// a MultiError sets the status, through its own Status or registered code, and
+// the kind, code and message of the body.
+func TestHTTPServerErrorWithWrappedMultiError(t *testing.T) {
+	const code = integrationBatchCode
+
+	errs := apperr.NewMultiError().
+		Add(0, "orders[0]", apperr.NewConflictError("duplicate order"))
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		kind   apperr.Kind
+		code   apperr.Code
+	}{
+		{"explicit status", apperr.Wrap(errs, apperr.Request, apperr.BadRequest, "batch rejected", apperr.WithStatus(http.StatusBadRequest)), http.StatusBadRequest, apperr.Request, apperr.BadRequest},
+		{"registered code", apperr.Wrap(errs, apperr.Validation, code, "batch rejected"), http.StatusUnprocessableEntity, apperr.Validation, code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(errHandler(tt.err))
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			var body struct {
+				Kind    string `json:"kind"`
+				Code    string `json:"code"`
+				Message string `json:"message"`
+				Errors  []struct {
+					Index int `json:"index"`
+					Error struct {
+						Kind string `json:"kind"`
+					} `json:"error"`
+				} `json:"errors"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, string(tt.kind), body.Kind)
+			assert.Equal(t, string(tt.code), body.Code)
+			assert.Equal(t, "batch rejected", body.Message)
+			require.Len(t, body.Errors, 1)
+			assert.Equal(t, string(apperr.Conflict), body.Errors[0].Error.Kind)
+		})
+	}
+}
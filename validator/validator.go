@@ -87,15 +87,13 @@ func validateArray(arr reflect.Value, validations []string) error {
 		}
 	}
 
+	errs := apperr.NewMultiError()
 	for i := 0; i < length; i++ {
 		v := arr.Index(i)
-		err := Validate(v, forwardedValidations...)
-		if err != nil {
-			return err
-		}
+		errs.Add(i, "", Validate(v, forwardedValidations...))
 	}
 
-	return nil
+	return errs.Err()
 }
 
 func validateMap(mp reflect.Value, validations []string) error {
@@ -246,13 +244,13 @@ func validateStruct(obj interface{}, validations []string) error {
 	}
 
 	validationsByField := extractValidationsByField(obj)
-	details := make(map[string]string)
+	details := make(map[string]error)
 
 	for field, validations := range validationsByField {
 		fieldValue := objValue.FieldByName(field)
 		err := Validate(fieldValue, validations...)
 		if err != nil {
-			details[field] = err.Error()
+			details[field] = err
 		}
 	}
 
@@ -260,7 +258,7 @@ func validateStruct(obj interface{}, validations []string) error {
 		return nil
 	}
 
-	maperr := apperr.NewMapError(details)
+	maperr := apperr.NewMapErrorFrom(details)
 	return apperr.Wrap(maperr, apperr.Validation, apperr.InvalidData, "invalid field(s)")
 }
 
@@ -190,3 +190,62 @@ func TestEnumValidation(t *testing.T) {
 	assert.True(t, errors.As(err, &appErr))
 	assert.Equal(t, appErr.Code, apperr.InvalidData)
 }
+
+func TestNestedStructDetails(t *testing.T) {
+	type ParentStruct struct {
+		Title  string `validate:"required"`
+		Sample StructSample
+	}
+
+	err := Validate(ParentStruct{Sample: StructSample{Age: 3}})
+	var mapErr *apperr.MapError
+	if !errors.As(err, &mapErr) {
+		t.Fatalf("Expected MapError, got %v", err)
+	}
+	assert.Equal(t, []string{"Sample", "Title"}, mapErr.Fields())
+
+	nested, ok := mapErr.Nested("Sample")
+	if !ok {
+		t.Fatal("Expected nested details for Sample")
+	}
+	assert.Equal(t, map[string]string{"Name": "required"}, nested.Details())
+}
+
+func TestArrayCollectsAllErrors(t *testing.T) {
+	err := Validate([]StructSample{{Name: "a"}, {Age: 1}, {Age: 2}})
+
+	var multi *apperr.MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("Expected MultiError, got %v", err)
+	}
+	assert.Equal(t, 2, multi.Len())
+	assert.Equal(t, 1, multi.Items[0].Index)
+	assert.Equal(t, 2, multi.Items[1].Index)
+	assert.Equal(t, apperr.Validation, multi.Kind())
+}
+
+func TestSliceFieldKeepsItemDetails(t *testing.T) {
+	type Item struct {
+		Name string `validate:"required"`
+		Age  int    `validate:"required"`
+	}
+	type Order struct {
+		Items []Item
+	}
+
+	err := Validate(Order{Items: []Item{{"a", 1}, {"", 2}, {"c", 0}}})
+	var mapErr *apperr.MapError
+	if !errors.As(err, &mapErr) {
+		t.Fatalf("Expected MapError, got %v", err)
+	}
+	items, ok := mapErr.Nested("Items")
+	if !ok {
+		t.Fatal("Expected nested details for Items")
+	}
+	assert.Equal(t, []string{"1", "2"}, items.Fields())
+
+	first, _ := items.Nested("1")
+	assert.Equal(t, map[string]string{"Name": "required"}, first.Details())
+	second, _ := items.Nested("2")
+	assert.Equal(t, map[string]string{"Age": "required"}, second.Details())
+}